@@ -0,0 +1,47 @@
+package main
+
+import (
+	"math"
+)
+
+// Pressure at the station itself in hPa, from the altimeter setting
+// reduced to the station's elevation through the standard atmosphere
+func (this Metar) stationPressure(elevation Height) float64 {
+	meters := float64(elevation.In("M"))
+	return float64(this.Pressure.In("hPa")) * math.Pow((288-0.0065*meters)/288, 5.2561)
+}
+
+// The altitude in the standard atmosphere with the station's pressure,
+// nil without an altimeter setting or a known elevation
+func (this Metar) PressureAltitude() (altitude *Height) {
+	elevation := this.Elevation()
+	if this.Pressure == nil || elevation == nil {
+		return
+	}
+	feet := 145366.45 * (1 - math.Pow(this.stationPressure(*elevation)/1013.25, 0.190284))
+	return optional(Height(math.Round(feet)))
+}
+
+// The altitude in the standard atmosphere with the air's density, how
+// high aircraft perform as if they were, by the NWS formula
+func (this Metar) DensityAltitude() (altitude *Height) {
+	elevation := this.Elevation()
+	if this.Pressure == nil || elevation == nil || this.Temperature == nil {
+		return
+	}
+	inHg := this.stationPressure(*elevation) / HPA_PER_INHG
+	rankine := float64(this.Temperature.In("F")) + 459.67
+	feet := 145442.16 * (1 - math.Pow(17.326*inHg/rankine, 0.235))
+	return optional(Height(math.Round(feet)))
+}
+
+// Estimated base of convective cloud above the ground, where rising air
+// cools to its dew point: about 400 ft for each degree of spread
+func (this Metar) CloudBase() (base *Height) {
+	depression := this.DewpointDepression()
+	if depression == nil {
+		return
+	}
+	feet := math.Max(float64(*depression), 0) * 400
+	return optional(Height(math.Round(feet/100) * 100))
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAltitudes(t *testing.T) {
+	type altitudeTestCase struct {
+		RawValue                 string
+		ExpectedElevation        Height
+		ExpectedPressureAltitude Height
+		ExpectedDensityAltitude  Height
+		ExpectedCloudBase        Height
+	}
+	testCases := []altitudeTestCase{
+		// standard pressure and temperature at the field
+		{"KORD 210051Z 18010KT 10SM CLR 15/15 A2992", 672, 673, 848, 0},
+		{"KDEN 210053Z 18010KT 10SM CLR 30/05 A3012", 5434, 5258, 8133, 10000},
+		{"KLXV 210053Z 18010KT 10SM CLR 20/M05 A3020", 9934, 9696, 12440, 10000},
+		{"EGLL 210050Z 24010KT CAVOK 15/08 Q1013", 83, 90, 128, 2800},
+		// the T group refines the spread
+		{"KORD 210051Z 18010KT 10SM CLR 15/11 A2992 RMK AO2 T01540108", 672, 673, 895, 1800},
+	}
+	for _, testCase := range testCases {
+		metar, err := ParseMetar(testCase.RawValue, testOptions)
+		if err != nil {
+			t.Errorf("Failed to parse %v: %v", testCase.RawValue, err)
+			continue
+		}
+		elevation, pressureAltitude := metar.Elevation(), metar.PressureAltitude()
+		densityAltitude, cloudBase := metar.DensityAltitude(), metar.CloudBase()
+		if elevation == nil || pressureAltitude == nil || densityAltitude == nil || cloudBase == nil {
+			t.Errorf("Missing altitudes for %v", testCase.RawValue)
+			continue
+		}
+		if *elevation != testCase.ExpectedElevation || *pressureAltitude != testCase.ExpectedPressureAltitude ||
+			*densityAltitude != testCase.ExpectedDensityAltitude || *cloudBase != testCase.ExpectedCloudBase {
+			t.Errorf("Wrong altitudes for %v: %v %v %v %v", testCase.RawValue, *elevation, *pressureAltitude,
+				*densityAltitude, *cloudBase)
+		}
+	}
+}
+
+func TestAltitudesUnknownStation(t *testing.T) {
+	metar, _ := ParseMetar("KXYZ 210051Z 18010KT 10SM CLR 15/10 A2992", testOptions)
+	if metar.Elevation() != nil || metar.PressureAltitude() != nil || metar.DensityAltitude() != nil {
+		t.Error("Altitudes without an elevation should be nil")
+	}
+	if metar.CloudBase() == nil || *metar.CloudBase() != 2000 {
+		t.Errorf("Wrong cloud base %v", metar.CloudBase())
+	}
+	details := GetDetailMetar(metar)
+	if !strings.Contains(details, "Elevation     : unknown, load a station list with -stations\n") ||
+		strings.Contains(details, "Pressure alt.") || !strings.Contains(details, "Cloud base    : 2000 ft, estimated\n") {
+		t.Errorf("Wrong altitude details %v", details)
+	}
+}
+
+func TestAltitudeDetails(t *testing.T) {
+	metar, _ := ParseMetar("KDEN 210053Z 18010KT 10SM CLR 30/05 A3012", testOptions)
+	details := GetDetailMetar(metar)
+	if !strings.Contains(details, "Pressure      : 30.12 inHg\nElevation     : 5434 ft\n"+
+		"Pressure alt. : 5258 ft\nDensity alt.  : 8133 ft\n") {
+		t.Errorf("Wrong altitude details %v", details)
+	}
+	if station, ok := LookupStation("KDEN"); !ok || station.Name != "Denver International" {
+		t.Errorf("Wrong station %+v", station)
+	}
+}
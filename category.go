@@ -0,0 +1,89 @@
+package main
+
+import (
+	"math"
+)
+
+// FAA flight categories, from best to worst
+const (
+	VFR  = "VFR"  // visual flight rules
+	MVFR = "MVFR" // marginal VFR
+	IFR  = "IFR"  // instrument flight rules
+	LIFR = "LIFR" // low IFR
+)
+
+// Height of the lowest broken, overcast or obscured layer with a known
+// base, nil if there's no ceiling
+func (this Conditions) Ceiling() (ceiling *Height) {
+	for _, layer := range this.Clouds {
+		switch layer.Coverage {
+		case "BKN", "OVC", "VV":
+			if layer.Base != nil && (ceiling == nil || *layer.Base < *ceiling) {
+				ceiling = layer.Base
+			}
+		}
+	}
+	return
+}
+
+// Reports whether a broken, overcast or obscured layer's height wasn't
+// reported, e.g. VV///, so the ceiling may be lower than Ceiling says
+func (this Conditions) CeilingUnknown() bool {
+	for _, layer := range this.Clouds {
+		switch layer.Coverage {
+		case "BKN", "OVC", "VV":
+			if layer.Base == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Reports whether the sky condition says anything about the ceiling: a
+// layer with a known base, or a clear sky
+func (this Conditions) hasCloudInformation() bool {
+	for _, layer := range this.Clouds {
+		switch {
+		case layer.Base != nil:
+			return true
+		case layer.Coverage == "SKC", layer.Coverage == "CLR", layer.Coverage == "NSC", layer.Coverage == "NCD":
+			return true
+		}
+	}
+	return false
+}
+
+// Classifies the conditions by ceiling and visibility, whichever is worse.
+// A missing ceiling or visibility doesn't limit the category, and with
+// neither reported there's no category.  With a layer of unknown height
+// the ceiling could be lower, so only LIFR is certain.
+func (this Conditions) FlightCategory() string {
+	visible := this.Visibility != nil && this.Visibility.Unit != ""
+	if !visible && !this.hasCloudInformation() {
+		return ""
+	}
+	feet := math.Inf(1)
+	if ceiling := this.Ceiling(); ceiling != nil {
+		feet = float64(*ceiling)
+	}
+	miles := math.Inf(1)
+	if visible {
+		// to the nearest sixteenth, as reported
+		miles = math.Round(float64(this.Visibility.Miles())*16) / 16
+		if this.Visibility.Qualifier == "M" {
+			miles = math.Nextafter(miles, 0)
+		}
+	}
+	switch {
+	case feet < 500 || miles < 1:
+		return LIFR
+	case this.CeilingUnknown():
+		return ""
+	case feet < 1000 || miles < 3:
+		return IFR
+	case feet <= 3000 || miles <= 5:
+		return MVFR
+	}
+	return VFR
+}
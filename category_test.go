@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFlightCategory(t *testing.T) {
+	type categoryTestCase struct {
+		RawValue         string
+		ExpectedCategory string
+		ExpectedCeiling  Height // 0 for no ceiling
+	}
+	testCases := []categoryTestCase{
+		{"KORD 210051Z 15007KT 10SM FEW040 SCT250 05/01 A3010", VFR, 0},
+		{"KORD 210051Z 15007KT 10SM BKN031 05/01 A3010", VFR, 3100},
+		{"KORD 210051Z 15007KT 10SM BKN030 05/01 A3010", MVFR, 3000},
+		{"KORD 210051Z 15007KT 5SM BR SCT008 05/04 A3010", MVFR, 0},
+		{"KORD 210051Z 15007KT 10SM FEW005 OVC012 BKN009 05/01 A3010", IFR, 900},
+		{"KORD 210051Z 15007KT 3SM BR OVC010 05/04 A3010", MVFR, 1000},
+		{"KORD 210051Z 15007KT 2 1/2SM BR OVC040 05/04 A3010", IFR, 4000},
+		{"KORD 210051Z 15007KT 1SM BR OVC040 05/04 A3010", IFR, 4000},
+		{"KORD 210051Z 15007KT M1SM FG OVC040 05/04 A3010", LIFR, 4000},
+		{"KORD 210051Z 15007KT 1/4SM FG VV002 05/05 A3010", LIFR, 200},
+		{"KORD 210051Z 15007KT 10SM OVC004 05/05 A3010", LIFR, 400},
+		{"EGLL 210050Z 24010KT 9999 BKN008 08/04 Q1012", IFR, 800},
+		{"EGLL 210050Z 24010KT 4000 BR SCT030 08/04 Q1012", IFR, 0},
+		{"EGLL 210050Z 24010KT CAVOK 08/04 Q1012", VFR, 0},
+		{"KORD 210051Z 15007KT OVC006 05/01 A3010", IFR, 600},
+		{"KORD 210051Z 15007KT 05/01 A3010", "", 0},
+		// failed sensors and layers of unknown height say nothing about the category
+		{"KABC 211155Z AUTO /////KT ////SM //////CB M /////", "", 0},
+		{"KORD 210051Z 15007KT 10SM VV/// 05/05 A3010", "", 0},
+		{"KORD 210051Z 15007KT 3SM BR BKN008 OVC/// 05/05 A3010", "", 800},
+		{"KORD 210051Z 15007KT //// BKN/// 05/05 A3010", "", 0},
+		{"KORD 210051Z 15007KT ////SM CLR 05/01 A3010", VFR, 0},
+		// unless the visibility is already as bad as it gets
+		{"KORD 210051Z 15007KT 1/4SM FG VV/// 05/05 A3010", LIFR, 0},
+	}
+	for _, testCase := range testCases {
+		metar, err := ParseMetar(testCase.RawValue, testOptions)
+		if err != nil {
+			t.Errorf("Failed to parse %v: %v", testCase.RawValue, err)
+			continue
+		}
+		if metar.FlightCategory() != testCase.ExpectedCategory {
+			t.Errorf("Wrong category for %v: %v", testCase.RawValue, metar.FlightCategory())
+		}
+		ceiling := metar.Ceiling()
+		if (ceiling == nil) != (testCase.ExpectedCeiling == 0) ||
+			(ceiling != nil && *ceiling != testCase.ExpectedCeiling) {
+			t.Errorf("Wrong ceiling for %v: %v", testCase.RawValue, ceiling)
+		}
+	}
+}
+
+func TestFlightCategoryDetails(t *testing.T) {
+	metar, _ := ParseMetar("KORD 210151Z 32012G20KT 2SM -SN BR BKN008 OVC015 M02/M03 A2992", testOptions)
+	details := GetDetailMetar(metar)
+	if !strings.Contains(details, "Ceiling       : 800 ft\nCategory      : IFR\n") {
+		t.Errorf("Wrong category details %v", details)
+	}
+	metar, _ = ParseMetar("KORD 210051Z VRB03KT", testOptions)
+	details = GetDetailMetar(metar)
+	if !strings.Contains(details, "Ceiling       : none\nCategory      : not reported\n") {
+		t.Errorf("Wrong category details %v", details)
+	}
+	metar, _ = ParseMetar("KORD 210051Z 15007KT 10SM VV/// 05/05 A3010", testOptions)
+	details = GetDetailMetar(metar)
+	if !strings.Contains(details, "Ceiling       : unknown\nCategory      : not reported\n") {
+		t.Errorf("Wrong category details %v", details)
+	}
+}
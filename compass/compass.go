@@ -3,10 +3,11 @@ package compass
 import "sort"
 
 var namePoints = [...]string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE",
-	"S", "SSW", "SW", "WSW", "W", "NWN", "NW", "NNW"}
+	"S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
 
-var breakpoints = [...]float64{16.88, 39.38, 61.88, 84.38, 106.88, 129.38, 151.88, 174.38,
-	196.88, 219.38, 241.88, 264.38, 286.88, 309.38, 331.88, 343.12}
+// Upper bound of each point's 22.5 degree sector
+var breakpoints = [...]float64{11.25, 33.75, 56.25, 78.75, 101.25, 123.75, 146.25, 168.75,
+	191.25, 213.75, 236.25, 258.75, 281.25, 303.75, 326.25, 348.75}
 
 //Get the compass abbreviation for cardinal or principal point (SE, NE, WSW, etc.) for the given degrees
 func GetCompassAbbreviation(point float32) (compassPoint string) {
@@ -12,6 +12,10 @@ func TestGetCompassPoints(t *testing.T) {
 		270: "W",
 		225: "SW",
 		359: "N",
+		240: "WSW",
+		290: "WNW",
+		340: "NNW",
+		350: "N",
 	}
 	for value, expected := range values {
 		result := GetCompassAbbreviation(value)
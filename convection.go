@@ -0,0 +1,124 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Lightning, a thunderstorm or a significant cloud, and where it is,
+// e.g. OCNL LTGICCG OHD, TS OHD MOV E or CB DSNT W MOV NE
+type LocatedPhenomenon struct {
+	Phenomenon     string   // LTG, TS, CB, CBMAM, TCU, ACC, ACSL, SCSL, CCSL or VIRGA
+	Frequency      string   // OCNL, FRQ or CONS for lightning, otherwise ""
+	LightningTypes []string // IC, CC, CG or CA
+	Distance       string   // OHD, VC, DSNT or "" for within sight
+	Directions     []string // a point, or the ends of a range like NE-SE, or ALQDS for all quadrants
+	Movement       string   // the point it's moving toward, or ""
+}
+
+// The eight points used for locations in remarks
+const compassPoint = `(N|NE|E|SE|S|SW|W|NW)`
+
+var lightningFrequencyRegex = regexp.MustCompile(`^(OCNL|FRQ|CONS)?$`)
+var lightningRegex = regexp.MustCompile(`^LTG((IC|CC|CG|CA)*)$`)
+var convectionRegex = regexp.MustCompile(`^(TS|CB|CBMAM|TCU|ACC|ACSL|SCSL|CCSL|VIRGA)$`)
+var convectionDistanceRegex = regexp.MustCompile(`^(OHD|VC|DSNT)?$`)
+var convectionDirectionRegex = regexp.MustCompile(`^(` + compassPoint + `(-` + compassPoint + `)?|ALQDS)?$`)
+var convectionMovementRegex = regexp.MustCompile(`^(MOV\s+` + compassPoint + `)?$`)
+
+var lightningFrequencies = map[string]string{
+	"OCNL": "occasional",
+	"FRQ":  "frequent",
+	"CONS": "continuous",
+}
+
+var lightningTypes = map[string]string{
+	"IC": "in-cloud",
+	"CC": "cloud-to-cloud",
+	"CG": "cloud-to-ground",
+	"CA": "cloud-to-air",
+}
+
+var convectionNames = map[string]string{
+	"LTG":   "lightning",
+	"TS":    "thunderstorm",
+	"CB":    "cumulonimbus",
+	"CBMAM": "cumulonimbus mammatus",
+	"TCU":   "towering cumulus",
+	"ACC":   "altocumulus castellanus",
+	"ACSL":  "standing lenticular altocumulus",
+	"SCSL":  "standing lenticular stratocumulus",
+	"CCSL":  "standing lenticular cirrocumulus",
+	"VIRGA": "virga",
+}
+
+var convectionDistances = map[string]string{
+	"OHD":  "overhead",
+	"VC":   "in the vicinity",
+	"DSNT": "distant",
+}
+
+var compassPointNames = map[string]string{
+	"N":  "north",
+	"NE": "northeast",
+	"E":  "east",
+	"SE": "southeast",
+	"S":  "south",
+	"SW": "southwest",
+	"W":  "west",
+	"NW": "northwest",
+}
+
+func (this *Metar) decodeLightning(values []string, units Units) string {
+	phenomenon := LocatedPhenomenon{Phenomenon: "LTG", Frequency: values[0]}
+	types := lightningRegex.FindStringSubmatch(values[1])[1]
+	for i := 0; i < len(types); i += 2 {
+		phenomenon.LightningTypes = append(phenomenon.LightningTypes, types[i:i+2])
+	}
+	phenomenon.locate(values[2:])
+	this.LocatedPhenomena = append(this.LocatedPhenomena, phenomenon)
+	return capitalize(phenomenon.String())
+}
+
+func (this *Metar) decodeConvection(values []string, units Units) string {
+	phenomenon := LocatedPhenomenon{Phenomenon: values[0]}
+	phenomenon.locate(values[1:])
+	this.LocatedPhenomena = append(this.LocatedPhenomena, phenomenon)
+	return capitalize(phenomenon.String())
+}
+
+// Fills in the distance, direction and movement, any of which may be empty
+func (this *LocatedPhenomenon) locate(values []string) {
+	this.Distance = values[0]
+	if values[1] != "" {
+		this.Directions = strings.Split(values[1], "-")
+	}
+	if fields := strings.Fields(values[2]); len(fields) == 2 {
+		this.Movement = fields[1]
+	}
+}
+
+// Describes the phenomenon and where it is, e.g. "occasional in-cloud
+// and cloud-to-ground lightning overhead" or "cumulonimbus distant to the
+// west moving northeast"
+func (this LocatedPhenomenon) String() string {
+	var types []string
+	for _, code := range this.LightningTypes {
+		types = append(types, lightningTypes[code])
+	}
+	description := joinWords(lightningFrequencies[this.Frequency], strings.Join(types, " and "),
+		convectionNames[this.Phenomenon], convectionDistances[this.Distance])
+	switch {
+	case len(this.Directions) == 1 && this.Directions[0] == "ALQDS":
+		description += " in all quadrants"
+	case len(this.Directions) == 1:
+		description += " to the " + compassPointNames[this.Directions[0]]
+	case len(this.Directions) == 2:
+		description += " to the " + compassPointNames[this.Directions[0]] + " through " +
+			compassPointNames[this.Directions[1]]
+	}
+	if this.Movement != "" {
+		description += " moving " + compassPointNames[this.Movement]
+	}
+	return description
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeLocatedPhenomena(t *testing.T) {
+	type locatedTestCase struct {
+		RemarksValue    string
+		Expected        LocatedPhenomenon
+		ExpectedRemarks string
+	}
+	testCases := []locatedTestCase{
+		{"OCNL LTGICCG OHD", LocatedPhenomenon{"LTG", "OCNL", []string{"IC", "CG"}, "OHD", nil, ""},
+			"Occasional in-cloud and cloud-to-ground lightning overhead"},
+		{"FRQ LTGCG DSNT NE-SE", LocatedPhenomenon{"LTG", "FRQ", []string{"CG"}, "DSNT", []string{"NE", "SE"}, ""},
+			"Frequent cloud-to-ground lightning distant to the northeast through southeast"},
+		{"LTG VC ALQDS", LocatedPhenomenon{"LTG", "", nil, "VC", []string{"ALQDS"}, ""},
+			"Lightning in the vicinity in all quadrants"},
+		{"TS OHD MOV E", LocatedPhenomenon{"TS", "", nil, "OHD", nil, "E"}, "Thunderstorm overhead moving east"},
+		{"CB DSNT W MOV NE", LocatedPhenomenon{"CB", "", nil, "DSNT", []string{"W"}, "NE"},
+			"Cumulonimbus distant to the west moving northeast"},
+		{"ACC NW", LocatedPhenomenon{"ACC", "", nil, "", []string{"NW"}, ""}, "Altocumulus castellanus to the northwest"},
+		{"VIRGA SW", LocatedPhenomenon{"VIRGA", "", nil, "", []string{"SW"}, ""}, "Virga to the southwest"},
+		{"TCU NE-E", LocatedPhenomenon{"TCU", "", nil, "", []string{"NE", "E"}, ""},
+			"Towering cumulus to the northeast through east"},
+		{"CBMAM", LocatedPhenomenon{"CBMAM", "", nil, "", nil, ""}, "Cumulonimbus mammatus"},
+		// what follows the location is a separate remark
+		{"TCU W SLP200", LocatedPhenomenon{"TCU", "", nil, "", []string{"W"}, ""},
+			"Towering cumulus to the west|Sea level pressure 1020.0 mb"},
+	}
+	for _, testCase := range testCases {
+		metar := Metar{}
+		metar.decodeRemarks(testCase.RemarksValue, Units{})
+		if len(metar.LocatedPhenomena) != 1 || !reflect.DeepEqual(metar.LocatedPhenomena[0], testCase.Expected) {
+			t.Errorf("Wrong phenomena for %v: %+v", testCase.RemarksValue, metar.LocatedPhenomena)
+		}
+		if strings.Join(metar.Remarks, "|") != testCase.ExpectedRemarks {
+			t.Errorf("Wrong remarks for %v: %q", testCase.RemarksValue, metar.Remarks)
+		}
+	}
+
+	metar := Metar{}
+	metar.decodeRemarks("OCNL TSNO", Units{})
+	if len(metar.LocatedPhenomena) != 0 {
+		t.Errorf("Shouldn't have located anything %+v", metar.LocatedPhenomena)
+	}
+}
+
+func TestParseMetarLocatedPhenomena(t *testing.T) {
+	metar, err := ParseMetar("KORD 210051Z 27015G25KT 3SM TSRA BKN030CB OVC080 22/20 A2992 "+
+		"RMK AO2 OCNL LTGICCG OHD TS OHD MOV E CB DSNT W MOV NE ACC NW VIRGA SW TCU NE-E SLP132", testOptions)
+	if err != nil {
+		t.Fatalf("Failed to parse but should've succeeded: %v", err)
+	}
+	if len(metar.LocatedPhenomena) != 6 {
+		t.Errorf("Received wrong count of phenomena %+v", metar.LocatedPhenomena)
+	}
+	details := GetDetailMetar(metar)
+	if !strings.Contains(details, "ASOS station\nOccasional in-cloud and cloud-to-ground lightning overhead\n"+
+		"Thunderstorm overhead moving east\nCumulonimbus distant to the west moving northeast\n"+
+		"Altocumulus castellanus to the northwest\nVirga to the southwest\n"+
+		"Towering cumulus to the northeast through east\nSea level pressure 1013.2 mb\n") {
+		t.Errorf("Wrong located details %v", details)
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Encodes a decoded report back into report text, with its groups in
+// standard order.  Groups that weren't reported are left out and tokens
+// that couldn't be decoded are dropped, so encoding a parsed report gives
+// its canonical form.
+func EncodeMetar(metar Metar) string {
+	var groups []string
+	if metar.Type != "" {
+		groups = append(groups, metar.Type)
+		// WMO reports put COR after the type, US reports after the time
+		if metar.Corrected {
+			groups = append(groups, "COR")
+		}
+	}
+	groups = append(groups, metar.Station, metar.Time.Format("021504Z"))
+	if metar.Type == "" && metar.Corrected {
+		groups = append(groups, "COR")
+	}
+	if metar.Nil {
+		return strings.Join(append(groups, "NIL"), " ")
+	}
+	if metar.Auto {
+		groups = append(groups, "AUTO")
+	}
+
+	groups = append(groups, encodeWind(metar.Wind)...)
+	groups = append(groups, encodeVisibility(metar.Visibility)...)
+	for _, runwayRange := range metar.RunwayVisualRanges {
+		groups = append(groups, encodeRunwayVisualRange(runwayRange))
+	}
+	groups = append(groups, encodeWeather(metar.Phenomena, "")...)
+	groups = append(groups, encodeClouds(metar.Clouds)...)
+	if metar.Temperature != nil || metar.Dewpoint != nil {
+		groups = append(groups, encodeTempDew(metar.Temperature, metar.Dewpoint))
+	}
+	if metar.Pressure != nil {
+		groups = append(groups, encodePressure(*metar.Pressure)...)
+	}
+	groups = append(groups, encodeWeather(metar.RecentWeather, "RE")...)
+	for _, runway := range metar.WindShear {
+		if runway == "ALL" {
+			groups = append(groups, "WS ALL RWY")
+		} else {
+			groups = append(groups, "WS R"+runway)
+		}
+	}
+	for _, trend := range metar.Trends {
+		groups = append(groups, encodeTrend(trend)...)
+	}
+	if metar.RawRemarks != "" {
+		groups = append(groups, "RMK "+strings.Join(strings.Fields(metar.RawRemarks), " "))
+	}
+	return strings.Join(groups, " ")
+}
+
+// Encodes the wind and its variable range, e.g. 32012G20KT 290V350.  A
+// wind without a unit, as when it's built by hand, is in knots.
+func encodeWind(wind Wind) (groups []string) {
+	unit := wind.WindUnit
+	if unit == "" {
+		if wind.WindSpeed == nil && wind.WindDirectionDegree == nil && !wind.WindVariable && !wind.WindCalm {
+			return
+		}
+		unit = "KT"
+	}
+	direction := "///"
+	switch {
+	case wind.WindVariable:
+		direction = "VRB"
+	case wind.WindDirectionDegree != nil:
+		direction = formatNumber(*wind.WindDirectionDegree, 3)
+	case wind.WindCalm:
+		direction = "000"
+	}
+	speed := "//"
+	switch {
+	case wind.WindSpeed != nil:
+		speed = qualifyNumber(wind.WindSpeedAbove, wind.WindSpeed.In(unit), 2)
+	case wind.WindCalm:
+		speed = "00"
+	}
+	gust := ""
+	if wind.WindGust != nil {
+		gust = "G" + qualifyNumber(wind.WindGustAbove, wind.WindGust.In(unit), 2)
+	}
+	groups = append(groups, direction+speed+gust+unit)
+	if wind.WindVariableFrom != nil && wind.WindVariableTo != nil {
+		groups = append(groups, formatNumber(*wind.WindVariableFrom, 3)+"V"+formatNumber(*wind.WindVariableTo, 3))
+	}
+	return
+}
+
+// Encodes the prevailing visibility in the unit it was reported in,
+// followed by any directional minimums, e.g. 1 1/2SM or 4000 1500SW.  A
+// visibility without a unit is in meters, unless it has no distance
+// either, when only the directional minimums were reported.
+func encodeVisibility(visibility *Visibility) (groups []string) {
+	if visibility == nil {
+		return
+	}
+	switch {
+	case visibility.CAVOK:
+		groups = append(groups, "CAVOK")
+	case visibility.Unit == "" && visibility.Meters == 0:
+	case visibility.Unit == "SM":
+		groups = append(groups, visibility.Qualifier+formatFraction(float64(visibility.Miles()))+"SM")
+	case visibility.Unit == "KM":
+		groups = append(groups, fmt.Sprintf("%s%vKM", visibility.Qualifier, visibility.Kilometers()))
+	case visibility.Meters >= 10000:
+		groups = append(groups, "9999")
+	default:
+		groups = append(groups, formatNumber(float32(visibility.Meters), 4))
+	}
+	for _, directional := range visibility.Directional {
+		groups = append(groups, formatNumber(float32(directional.Meters), 4)+directional.Direction)
+	}
+	return
+}
+
+// Encodes a runway visual range, e.g. R09/0600V1200FT/U or R27/M0050N
+func encodeRunwayVisualRange(runwayRange RunwayVisualRange) string {
+	group := fmt.Sprintf("R%s/%s%s", runwayRange.Runway, runwayRange.MinQualifier, formatNumber(runwayRange.Min, 4))
+	if runwayRange.Max != runwayRange.Min || runwayRange.MaxQualifier != runwayRange.MinQualifier {
+		group += "V" + runwayRange.MaxQualifier + formatNumber(runwayRange.Max, 4)
+	}
+	if runwayRange.Unit == "FT" {
+		group += "FT"
+		if runwayRange.Tendency != "" {
+			group += "/"
+		}
+	}
+	return group + runwayRange.Tendency
+}
+
+// Encodes each weather group with the given prefix, e.g. -FZRA or RERA
+func encodeWeather(phenomena Phenomena, prefix string) (groups []string) {
+	for _, phenomenon := range phenomena {
+		groups = append(groups, prefix+phenomenon.Intensity+phenomenon.Descriptor+
+			strings.Join(phenomenon.Precipitation, "")+phenomenon.Obscuration+phenomenon.Other)
+	}
+	return
+}
+
+// Encodes each sky-condition layer, e.g. BKN035CB or VV003
+func encodeClouds(clouds []CloudLayer) (groups []string) {
+	for _, layer := range clouds {
+		if layer.Clear() {
+			groups = append(groups, layer.Coverage)
+			continue
+		}
+		base := "///"
+		if layer.Base != nil {
+			base = formatNumber(float32(*layer.Base)/100, 3)
+		}
+		groups = append(groups, layer.Coverage+base+layer.Convective)
+	}
+	return
+}
+
+// Encodes the temperature and dew point, e.g. M02/M03, 05/ or ///M03
+func encodeTempDew(temperature *Temperature, dewPoint *Temperature) string {
+	group := "//"
+	if temperature != nil {
+		group = formatSignedNumber(*temperature)
+	}
+	group += "/"
+	if dewPoint != nil {
+		group += formatSignedNumber(*dewPoint)
+	}
+	return group
+}
+
+// Encodes the altimeter setting in each unit it has a value in, the
+// reported unit first
+func encodePressure(pressure Pressure) (groups []string) {
+	if pressure.InHg != 0 {
+		groups = append(groups, "A"+formatNumber(pressure.InHg*100, 4))
+	}
+	if pressure.HPa != 0 {
+		groups = append(groups, "Q"+formatNumber(pressure.HPa, 4))
+	}
+	if pressure.Unit == "hPa" && len(groups) == 2 {
+		groups[0], groups[1] = groups[1], groups[0]
+	}
+	return
+}
+
+// Encodes a change group, e.g. BECMG FM1100 TL1200 27015G25KT 3000 -SHRA BKN015
+func encodeTrend(trend Trend) (groups []string) {
+	groups = append(groups, trend.Type)
+	if trend.From != nil {
+		groups = append(groups, "FM"+trend.From.Format("1504"))
+	}
+	if trend.Until != nil {
+		groups = append(groups, "TL"+trend.Until.Format("1504"))
+	}
+	if trend.At != nil {
+		groups = append(groups, "AT"+trend.At.Format("1504"))
+	}
+	return append(groups, encodeConditions(trend.Conditions, trend.NoSignificantWeather)...)
+}
+
+// Encodes the groups forecast by a trend or TAF change group, e.g. 27015G25KT 3000 -SHRA BKN015
+func encodeConditions(conditions Conditions, noSignificantWeather bool) (groups []string) {
+	groups = append(groups, encodeWind(conditions.Wind)...)
+	groups = append(groups, encodeVisibility(conditions.Visibility)...)
+	groups = append(groups, encodeWeather(conditions.Phenomena, "")...)
+	if noSignificantWeather {
+		groups = append(groups, "NSW")
+	}
+	return append(groups, encodeClouds(conditions.Clouds)...)
+}
+
+// Rounds a value to a whole number zero-padded to the given width
+func formatNumber(value float32, width int) string {
+	return fmt.Sprintf("%0*d", width, int(math.Round(float64(value))))
+}
+
+// Formats a value like formatNumber, prefixed with P if it's "greater than"
+func qualifyNumber(above bool, value float32, width int) string {
+	if above {
+		return "P" + formatNumber(value, width)
+	}
+	return formatNumber(value, width)
+}
+
+// Formats a whole number of degrees with M for minus, e.g. M03.  Tenths
+// round half up as FMH-1 has it, so -1.5 is M01, and values just below
+// zero, including the -0 that M00 decodes to, are M00.
+func formatSignedNumber(value Temperature) string {
+	rounded := math.Floor(float64(value) + 0.5)
+	if math.Signbit(float64(value)) {
+		return "M" + formatNumber(float32(-rounded), 2)
+	}
+	return formatNumber(float32(rounded), 2)
+}
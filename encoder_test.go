@@ -0,0 +1,189 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeMetar(t *testing.T) {
+	type encodeTestCase struct {
+		RawValue string
+		Expected string
+	}
+	testCases := []encodeTestCase{
+		{"KORD 210051Z 15007KT 10SM OVC060 05/01 A3010 RMK AO2 RAE02 SLP200 P0000 T00500011",
+			"KORD 210051Z 15007KT 10SM OVC060 05/01 A3010 RMK AO2 RAE02 SLP200 P0000 T00500011"},
+		{"KPWK 300251Z 16009KT 10SM CLR 00/M07 A3043 RMK AO2 SLP312 T00001067 58019",
+			"KPWK 300251Z 16009KT 10SM CLR 00/M07 A3043 RMK AO2 SLP312 T00001067 58019"},
+		{"KPWK 300251Z 16009KT 10SM CLR M00/M07 A3043 RMK AO2 T1004",
+			"KPWK 300251Z 16009KT 10SM CLR M00/M07 A3043 RMK AO2 T1004"},
+		{"KORD 210051Z 15007KT 10SM CLR M01/M02 A3010 RMK AO2 T10151015",
+			"KORD 210051Z 15007KT 10SM CLR M01/M01 A3010 RMK AO2 T10151015"},
+		{"KORD 210051Z 15007KT 10SM CLR M00/M00 A3010 RMK AO2 T10031005",
+			"KORD 210051Z 15007KT 10SM CLR M00/M00 A3010 RMK AO2 T10031005"},
+		{"KORD 210051Z 15007KT 10SM CLR 02/01 A3010 RMK AO2 T00151005",
+			"KORD 210051Z 15007KT 10SM CLR 02/M00 A3010 RMK AO2 T00151005"},
+		{"METAR COR LFPG 210100Z 24008KT 9999 FEW030 09/05 Q1015 NOSIG=",
+			"METAR COR LFPG 210100Z 24008KT 9999 FEW030 09/05 Q1015 NOSIG"},
+		{"KORD 210051Z COR 15007KT 10SM OVC060 05/01 A3010", "KORD 210051Z COR 15007KT 10SM OVC060 05/01 A3010"},
+		{"METAR KXYZ 211200Z NIL=", "METAR KXYZ 211200Z NIL"},
+		{"KORD  210051Z 21012KT 180V240   10SM OVC060 05/01 A3010 RMK  AO2   SLP200 =",
+			"KORD 210051Z 21012KT 180V240 10SM OVC060 05/01 A3010 RMK AO2 SLP200"},
+		{"KORD 210051Z 15007KT 1 1/2SM R28L/2400FT R09/0600V1200FT/U -SHRA BR FEW005 BKN010CB M00/M01 A2992",
+			"KORD 210051Z 15007KT 1 1/2SM R28L/2400FT R09/0600V1200FT/U -SHRA BR FEW005 BKN010CB M00/M01 A2992"},
+		{"KDEN 210051Z 250105G130KT M1/4SM VV003 M05/ A2992", "KDEN 210051Z 250105G130KT M1/4SM VV003 M05/ A2992"},
+		{"LSZH 210050Z VRB02KT 0800 1500SW R27/M0050N FG SCT///CB ///015 01/01 Q1013 A2991",
+			"LSZH 210050Z VRB02KT 0800 1500SW R27/M0050N FG SCT///CB ///015 01/01 Q1013 A2991"},
+		{"UUEE 210100Z 09008MPS CAVOK M12/M15 Q1030 RESN WS RWY24 WS ALL RWY",
+			"UUEE 210100Z 09008MPS CAVOK M12/M15 Q1030 RESN WS R24 WS ALL RWY"},
+		{"EGLL 210050Z 24010KT 9999 SCT030 08/04 Q1012 " +
+			"BECMG FM1100 TL1200 27015G25KT 3000 -SHRA BKN015 TEMPO 4000 NSW NSC",
+			"EGLL 210050Z 24010KT 9999 SCT030 08/04 Q1012 " +
+				"BECMG FM1100 TL1200 27015G25KT 3000 -SHRA BKN015 TEMPO 4000 NSW NSC"},
+		// unavailable groups and unrecognized tokens are left out
+		{"KXYZ 210055Z AUTO /////KT ////SM // //////CB BKN/// ///// M A//// RMK AO2 PWINO TSNO $",
+			"KXYZ 210055Z AUTO /////KT //////CB BKN/// RMK AO2 PWINO TSNO $"},
+		{"KORD 210051Z 15007KT 10SM XYZZY OVC060 05/01 A3010", "KORD 210051Z 15007KT 10SM OVC060 05/01 A3010"},
+		{"EGLL 211150Z 24015KT //// 1500SW BKN015 12/08 Q1013", "EGLL 211150Z 24015KT 1500SW BKN015 12/08 Q1013"},
+	}
+	for _, testCase := range testCases {
+		metar, err := ParseMetar(testCase.RawValue, testOptions)
+		if err != nil {
+			t.Errorf("Failed to parse %v: %v", testCase.RawValue, err)
+			continue
+		}
+		encoded := EncodeMetar(metar)
+		if encoded != testCase.Expected {
+			t.Errorf("Wrong encoding for %v: %v", testCase.RawValue, encoded)
+		}
+	}
+}
+
+func TestEncodeHandBuiltMetar(t *testing.T) {
+	type handBuiltTestCase struct {
+		Metar    Metar
+		Expected string
+	}
+	observed := time.Date(2013, time.January, 21, 0, 51, 0, 0, time.UTC)
+	overcast := []CloudLayer{{Coverage: "OVC", Base: optional[Height](6000)}}
+	testCases := []handBuiltTestCase{
+		{Metar{Conditions: Conditions{Wind{WindDirectionDegree: optional[float32](150), WindSpeed: optional[Speed](7)},
+			&Visibility{Meters: 8000}, nil, overcast}, Station: "EGLL", Time: observed, Pressure: &Pressure{HPa: 1013}},
+			"EGLL 210051Z 15007KT 8000 OVC060 Q1013"},
+		{Metar{Conditions: Conditions{Wind{WindVariable: true, WindSpeed: optional[Speed](3)},
+			&Visibility{Meters: 12000}, nil, overcast}, Station: "EGLL", Time: observed, Pressure: &Pressure{InHg: 29.92}},
+			"EGLL 210051Z VRB03KT 9999 OVC060 A2992"},
+		{Metar{Conditions: Conditions{Wind{WindCalm: true}, nil, nil, overcast}, Station: "KORD", Time: observed,
+			Temperature: optional[Temperature](5), Dewpoint: optional[Temperature](1),
+			Pressure: &Pressure{InHg: 29.92, HPa: 1013}},
+			"KORD 210051Z 00000KT OVC060 05/01 A2992 Q1013"},
+		{Metar{Conditions: Conditions{Clouds: overcast}, Station: "KORD", Time: observed, Pressure: &Pressure{}},
+			"KORD 210051Z OVC060"},
+	}
+	for _, testCase := range testCases {
+		encoded := EncodeMetar(testCase.Metar)
+		if encoded != testCase.Expected {
+			t.Errorf("Wrong encoding of %+v: %v", testCase.Metar, encoded)
+		}
+		if _, err := ParseMetar(encoded, strictTestOptions); err != nil {
+			t.Errorf("Encoding %v isn't well-formed: %v", encoded, err)
+		}
+	}
+}
+
+func TestEncodeMetarRoundTrip(t *testing.T) {
+	// every report in parser_test.go that has a station and time
+	testMetars := []string{
+		"KORD 210051Z 15007KT 10SM OVC060 05/01 A3010 RMK AO2 RAE02 SLP200 P0000 T00500011",
+		"PANV 260236Z AUTO 04014G19KT 10SM OVC085 M11/M14 A2989 RMK AO1",
+		"KPWK 300251Z 16009KT 10SM FEW150 BKN200 OVC250 00/M07 A3043 RMK AO2 SLP312 T00001067 58019",
+		"KPWK 300252Z 15007KT 10SM CLR 00/M07 A3045 RMK AO2 SLP318 T00001067 58020",
+		"KORD 210151Z 32012G20KT 2SM -SN BR BKN008 OVC015 M02/M03 A2992 RMK AO2",
+		"KMDW 211253Z 27010KT 10SM FEW250 08/02 A3001",
+		"KORD 210051Z 15007KT 10SM XYZZY OVC060 05/01 A3010",
+		"KORD 210151Z 32012KT 2SM -TSRA BR +FZRA OVC015 M02/M03 A2992",
+		"METAR KORD 210051Z 15007KT 10SM OVC060 05/01 A3010=",
+		"SPECI KORD 210112Z AUTO 15007KT 2SM BR OVC006 05/04 A3010",
+		"KORD 210051Z COR 15007KT 10SM OVC060 05/01 A3010",
+		"METAR COR LFPG 210100Z 24008KT 9999 FEW030 09/05 Q1015 NOSIG=",
+		"KXYZ 211200Z NIL",
+		"METAR KXYZ 211200Z NIL=",
+		"KORD 210051Z 15007KT 10SM OVC060 05/01 A3010 RMK AO2=",
+		"KORD 210051Z 15007KT A3010",
+		"KORD 210051Z VRB03KT",
+		"KORD 210051Z 1507KT 10SM OVC060 05/01 A3010",
+		"KORD 210051Z 15007KT 10/SM OVC060 05/01 A3010",
+		"KORD 210051Z 15007KT 10SM OVC60 05/01 A3010",
+		"KORD 210051Z 15007KT 10SM -XXRA OVC060 05/01 A3010",
+		"KORD 210051Z 15007KT 10SM OVC060 5/01 A3010",
+		"KORD 210051Z 15007KT 10SM OVC060 05/01 A301",
+		"KORD 210051Z 15007KT 10SM OVC060 05/01 A3010 RMK",
+		"KORD 210051Z 15007KT OVC060 10SM 05/01 A3010",
+		"EGLL 210050Z 24010KT 9999 SCT030 08/04 Q1012 TEMPO 4000 XYZZY",
+		"KXYZ 210055Z AUTO /////KT ////SM // ///// M A//// RMK AO2",
+		"KORD 210051Z 15007KT 10SM OVC060 05/01 A3010",
+		"KORD 210051Z 21012KT 180V240 10SM OVC060 05/01 A3010",
+		"KORD 210051Z 15007KT 1/4SM FG VV002 BKN010CB OVC020 05/05 A3010",
+		"KXYZ 210055Z AUTO /////KT ////SM // //////CB BKN/// ///// M A//// RMK AO2 PWINO TSNO $",
+		"MMMX 210046Z 36005KT 7SM SCT200 18/M02 A3012 Q1020",
+		"EGLL 211150Z 24015KT //// 1500SW BKN015 12/08 Q1013",
+		"KPWK 300251Z 16009KT 10SM CLR 00/M07 A3043 RMK AO2 SLP312 T00001067 58019",
+		"KPWK 300251Z 16009KT 10SM CLR M00/M07 A3043 RMK AO2 T1004",
+		"KPWK 300251Z 16009KT 10SM CLR 00/M07 A3043 RMK AO2",
+		"KORD 210051Z 15007KT 10SM CLR M01/M02 A3010 RMK AO2 T10151015",
+		"KORD 210051Z 15007KT 10SM CLR M00/M00 A3010 RMK AO2 T10031005",
+	}
+	for _, testMetar := range testMetars {
+		metar, err := ParseMetar(testMetar, testOptions)
+		if err != nil {
+			t.Errorf("Failed to parse %v: %v", testMetar, err)
+			continue
+		}
+		encoded := EncodeMetar(metar)
+		reparsed, err := ParseMetar(encoded, strictTestOptions)
+		if err != nil {
+			t.Errorf("Encoding of %v isn't well-formed: %v", testMetar, err)
+			continue
+		}
+		metar.Unparsed = nil
+		if !reflect.DeepEqual(metar, reparsed) {
+			t.Errorf("Round trip of %v through %v gave %+v", testMetar, encoded, reparsed)
+		}
+		if metar.FlightCategory() != reparsed.FlightCategory() {
+			t.Errorf("Round trip of %v through %v changed the category from %v to %v", testMetar, encoded,
+				metar.FlightCategory(), reparsed.FlightCategory())
+		}
+	}
+}
+
+func TestEncodeTempDewBelowZero(t *testing.T) {
+	if encodeTempDew(optional[Temperature](-0.3), optional[Temperature](-1.6)) != "M00/M02" {
+		t.Error("Wrong rounding below zero")
+	}
+	if encodeTempDew(optional[Temperature](-1.5), optional[Temperature](-0.5)) != "M01/M00" {
+		t.Error("Wrong rounding of halves below zero")
+	}
+	if encodeTempDew(nil, optional[Temperature](4)) != "///04" {
+		t.Error("Wrong missing temperature")
+	}
+}
+
+func TestNormalizeMetars(t *testing.T) {
+	input := "KORD  210051Z 15007KT 10SM OVC060 05/01 A3010=\n\n" +
+		"METAR   KMDW 211253Z 27010KT 10SM FEW250 08/02 A3001 RMK AO2\n"
+	normalized, err := NormalizeMetars(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to normalize: %v", err)
+	}
+	if normalized != "KORD 210051Z 15007KT 10SM OVC060 05/01 A3010\n"+
+		"METAR KMDW 211253Z 27010KT 10SM FEW250 08/02 A3001 RMK AO2" {
+		t.Errorf("Wrong normalized reports %v", normalized)
+	}
+
+	_, err = NormalizeMetars(strings.NewReader("KORD 210051Z 15007KT 10SM OVC060 05/01 A3010\n15007KT 10SM\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2: ") {
+		t.Errorf("Expected an error for line 2, got %v", err)
+	}
+}
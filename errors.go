@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+)
+
+// A failure to decode one group of a raw report
+type ParseError struct {
+	Group  string // wind, visibility, clouds, temp/dew, altimeter, remarks, etc., empty if unrecognized
+	Token  string // the offending token, empty if the group is missing entirely
+	Offset int    // byte offset of the token within the raw report
+	Reason string
+}
+
+func (this *ParseError) Error() string {
+	if this.Token == "" {
+		return fmt.Sprintf("%s: %s", this.Group, this.Reason)
+	}
+	if this.Group == "" {
+		return fmt.Sprintf("%s %q at offset %d", this.Reason, this.Token, this.Offset)
+	}
+	return fmt.Sprintf("%s: %s %q at offset %d", this.Group, this.Reason, this.Token, this.Offset)
+}
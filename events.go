@@ -0,0 +1,92 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// When a phenomenon began or ended, from remarks like RAB15E30 or TSB0159
+type WeatherEvent struct {
+	Phenomenon Phenomenon
+	Began      bool      // false when it ended
+	Time       time.Time // at or before the observation
+}
+
+// One or more phenomena, each followed by when it began and ended, e.g. FZRAB12E25SNB25
+var weatherEventsRegex = regexp.MustCompile(`^([A-Z]+?([BE](\d{4}|\d{2}))+)+$`)
+
+var weatherEventRegex = regexp.MustCompile(`([A-Z]+?)((?:[BE](?:\d{4}|\d{2}))+)`)
+
+var eventTimeRegex = regexp.MustCompile(`([BE])(\d{4}|\d{2})`)
+
+// Parses a begin/end remark, placing each time before the observation.
+// A phenomenon's times are resolved backwards from its last, each being
+// the latest it could be before the one after it, so it always ends after
+// it began.  Returns nil if the remark isn't one.
+func parseWeatherEvents(remark string, observed time.Time) (events []WeatherEvent) {
+	if !weatherEventsRegex.MatchString(remark) {
+		return nil
+	}
+	for _, matches := range weatherEventRegex.FindAllStringSubmatch(remark, -1) {
+		if !weatherRegex.MatchString(matches[1]) {
+			return nil
+		}
+		phenomenon := parseWeather(matches[1])
+		timeMatches := eventTimeRegex.FindAllStringSubmatch(matches[2], -1)
+		phenomenonEvents := make([]WeatherEvent, len(timeMatches))
+		latest := observed
+		for i := len(timeMatches) - 1; i >= 0; i-- {
+			latest = resolveRemarkTime(timeMatches[i][2], latest)
+			phenomenonEvents[i] = WeatherEvent{phenomenon, timeMatches[i][1] == "B", latest}
+		}
+		events = append(events, phenomenonEvents...)
+	}
+	return
+}
+
+// Places a remark time, minutes past the hour or hours and minutes, at
+// the latest time on or before the given one it could be
+func resolveRemarkTime(clock string, observed time.Time) (resolved time.Time) {
+	minute, _ := strconv.Atoi(clock[len(clock)-2:])
+	if len(clock) == 2 {
+		resolved = observed.Truncate(time.Hour).Add(time.Duration(minute) * time.Minute)
+		if resolved.After(observed) {
+			resolved = resolved.Add(-time.Hour)
+		}
+		return
+	}
+	hour, _ := strconv.Atoi(clock[:2])
+	resolved = time.Date(observed.Year(), observed.Month(), observed.Day(), hour, minute, 0, 0, observed.Location())
+	if resolved.After(observed) {
+		resolved = resolved.AddDate(0, 0, -1)
+	}
+	return
+}
+
+// Describes the event, e.g. "rain began at 00:15"
+func (this WeatherEvent) String() string {
+	return this.Phenomenon.String() + " " + this.verb() + " at " + this.Time.Format("15:04")
+}
+
+func (this WeatherEvent) verb() string {
+	if this.Began {
+		return "began"
+	}
+	return "ended"
+}
+
+// Describes the events as a sentence, joining those of the same phenomenon,
+// e.g. "Freezing rain began at 00:12 and ended at 00:25, snow began at 00:25"
+func describeWeatherEvents(events []WeatherEvent) string {
+	var clauses []string
+	for i, event := range events {
+		if i > 0 && events[i-1].Phenomenon.String() == event.Phenomenon.String() {
+			clauses[len(clauses)-1] += " and " + event.verb() + " at " + event.Time.Format("15:04")
+		} else {
+			clauses = append(clauses, event.String())
+		}
+	}
+	return capitalize(strings.Join(clauses, ", "))
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseWeatherEvents(t *testing.T) {
+	observed := time.Date(2013, time.January, 21, 0, 51, 0, 0, time.UTC)
+	type eventTestCase struct {
+		RemarkValue    string
+		ExpectedEvents string
+		ExpectedText   string
+	}
+	testCases := []eventTestCase{
+		{"RAE02", "rain ended at 00:02", "Rain ended at 00:02"},
+		// a minute past the observation's is from the hour before
+		{"SNB55", "snow began at 23:55", "Snow began at 23:55"},
+		{"TSB2359E30", "thunderstorm began at 23:59|thunderstorm ended at 00:30",
+			"Thunderstorm began at 23:59 and ended at 00:30"},
+		// a begin time is before the end that follows it
+		{"RAB40E10", "rain began at 23:40|rain ended at 00:10", "Rain began at 23:40 and ended at 00:10"},
+		{"SHSNB20E55", "snow showers began at 23:20|snow showers ended at 23:55",
+			"Snow showers began at 23:20 and ended at 23:55"},
+		{"FZRAB12E25SNB25", "freezing rain began at 00:12|freezing rain ended at 00:25|snow began at 00:25",
+			"Freezing rain began at 00:12 and ended at 00:25, snow began at 00:25"},
+		{"SHRAB05E30SHSNB20E45", "rain showers began at 00:05|rain showers ended at 00:30|" +
+			"snow showers began at 00:20|snow showers ended at 00:45",
+			"Rain showers began at 00:05 and ended at 00:30, snow showers began at 00:20 and ended at 00:45"},
+		{"SLP200", "", ""},
+		{"RVRNO", "", ""},
+		{"XXB12", "", ""},
+	}
+	for _, testCase := range testCases {
+		events := parseWeatherEvents(testCase.RemarkValue, observed)
+		var descriptions []string
+		for _, event := range events {
+			descriptions = append(descriptions, event.String())
+		}
+		if strings.Join(descriptions, "|") != testCase.ExpectedEvents {
+			t.Errorf("Wrong events for %v: %v", testCase.RemarkValue, descriptions)
+		}
+		if events != nil && describeWeatherEvents(events) != testCase.ExpectedText {
+			t.Errorf("Wrong description for %v: %v", testCase.RemarkValue, describeWeatherEvents(events))
+		}
+	}
+
+	events := parseWeatherEvents("TSB2359E30", observed)
+	if !events[0].Time.Equal(time.Date(2013, time.January, 20, 23, 59, 0, 0, time.UTC)) || !events[0].Began ||
+		events[0].Phenomenon.Descriptor != "TS" {
+		t.Errorf("Wrong event %+v", events[0])
+	}
+	for _, remark := range []string{"TSB2359E30", "RAB40E10", "SHSNB20E55", "SNB10E20B30E40"} {
+		events := parseWeatherEvents(remark, observed)
+		for i := 1; i < len(events); i++ {
+			if events[i].Time.Before(events[i-1].Time) || events[i].Time.Sub(events[i-1].Time) > time.Hour {
+				t.Errorf("Events out of order for %v: %v then %v", remark, events[i-1], events[i])
+			}
+		}
+	}
+}
+
+func TestParseMetarWeatherEvents(t *testing.T) {
+	metar, err := ParseMetar("KORD 210051Z 15007KT 10SM OVC060 05/01 A3010 RMK AO2 RAE02 SLP200 P0000 T00500011",
+		testOptions)
+	if err != nil {
+		t.Fatalf("Failed to parse but should've succeeded: %v", err)
+	}
+	if len(metar.WeatherEvents) != 1 || metar.WeatherEvents[0].Began ||
+		!metar.WeatherEvents[0].Time.Equal(time.Date(2013, time.January, 21, 0, 2, 0, 0, time.UTC)) {
+		t.Errorf("Wrong weather events %+v", metar.WeatherEvents)
+	}
+	details := GetDetailMetar(metar)
+	if !strings.Contains(details, "\nRain ended at 00:02\n") {
+		t.Errorf("Wrong event details %v", details)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"flag"
 	"fmt"
@@ -10,15 +11,21 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"text/tabwriter"
 	"text/template"
+	"time"
 )
 
 var Output io.Writer
+var Input io.Reader
 
 const METAR_PATH = "http://weather.noaa.gov/pub/data/observations/metar/stations/"
+const TAF_PATH = "http://weather.noaa.gov/pub/data/forecasts/taf/stations/"
 const METAR_LIST_REF = "http://www.cnrfc.noaa.gov/metar.php"
+const METAR_DATE_FORMAT = "2006/01/02 15:04"
 
-var decode, verbose, search, help bool
+var decode, verbose, search, help, normalize, forecast, timeline bool
+var pressureUnit, parseMode, unitSystem, stationList string
 var flagSet *flag.FlagSet
 
 func init() {
@@ -27,7 +34,25 @@ func init() {
 	flagSet.BoolVar(&verbose, "v", false, "Be verbose")
 	flagSet.BoolVar(&search, "s", false, "Search")
 	flagSet.BoolVar(&help, "h", false, "Help")
+	flagSet.BoolVar(&forecast, "t", false, "Fetch and decode the TAF instead of the METAR")
+	flagSet.BoolVar(&timeline, "l", false, "List the TAF hour by hour")
+	flagSet.BoolVar(&normalize, "n", false, "Normalize reports read from standard input, one per line")
+	flagSet.StringVar(&unitSystem, "units", "", "Units to show: metric, imperial, aviation or si, defaults to the units reported")
+	flagSet.StringVar(&pressureUnit, "p", "", "Pressure unit (inHg, hPa or Pa), overriding -units")
+	flagSet.StringVar(&parseMode, "m", "lenient", "Parse mode: lenient skips groups it can't decode, strict rejects the report")
+	flagSet.StringVar(&stationList, "stations", "", "Station list in the OurAirports airports.csv format, for field elevations")
 	Output = os.Stdout
+	Input = os.Stdin
+}
+
+// Builds the parse options selected on the command line
+func getParseOptions(reference time.Time) (options ParseOptions) {
+	options.Reference = reference
+	options.Units = UnitSystem(unitSystem)
+	if parseMode == "strict" {
+		options.Mode = Strict
+	}
+	return
 }
 
 //Command-line entry point
@@ -35,43 +60,48 @@ func main() {
 	args, valid := ParseArgs(os.Args[1:])
 	if valid {
 		var result string
-		var success bool
-		if search {
+		var err error
+		if stationList != "" {
+			err = LoadStationList(stationList)
+		}
+		switch {
+		case err != nil:
+			// the station list couldn't be loaded
+		case search:
 			var resultList []string
-			resultList, success = SearchStations(args.Args()[0])
+			resultList, err = SearchStations(args.Args()[0])
 			result = strings.Join(resultList, "\n")
-		} else {
-			result, success = GetMetar(args.Args())
+		case normalize:
+			result, err = NormalizeMetars(Input)
+		case forecast || timeline:
+			result, err = GetTaf(args.Args())
+		default:
+			result, err = GetMetar(args.Args())
 		}
-		if success {
+		if err == nil {
 			fmt.Fprint(Output, result, "\n")
 
 		} else {
-			fmt.Fprint(Output, "Oh no, something went wrong!\n")
+			fmt.Fprintf(Output, "Oh no, something went wrong!\n%v\n", err)
 		}
 	}
 }
 
 //Retrieve the METAR for the given station
-//Returns the string and a status
-func GetMetar(stations []string) (value string, ok bool) {
+//Returns the string, or an error if the station couldn't be fetched or decoded
+func GetMetar(stations []string) (value string, err error) {
 	for _, station := range stations {
 		var stationMetar string
 		station = strings.ToUpper(station)
-		resp, err := http.Get(METAR_PATH + station + ".TXT")
+		reference, lines, err := fetchStationFile(METAR_PATH, station)
 		if err != nil {
-			return
+			return value, err
 		}
-		defer resp.Body.Close()
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return
-		}
-		metarLine := strings.Split(string(body), "\n")[1]
+		metarLine := lines[0]
 		if decode {
-			decodedValue, ok := DecodeMetar(metarLine)
-			if !ok {
-				return value, ok
+			decodedValue, err := DecodeMetar(metarLine, getParseOptions(reference))
+			if err != nil {
+				return value, fmt.Errorf("%s: unable to decode report: %v", station, err)
 			}
 			stationMetar = fmt.Sprintf("%s\n%s", metarLine, decodedValue)
 		} else {
@@ -81,7 +111,99 @@ func GetMetar(stations []string) (value string, ok bool) {
 		value += stationMetar + "\n"
 	}
 
-	return value, true
+	return value, nil
+}
+
+//Retrieve the TAF for the given station
+//Returns the string, or an error if the station couldn't be fetched or decoded
+func GetTaf(stations []string) (value string, err error) {
+	for _, station := range stations {
+		station = strings.ToUpper(station)
+		reference, lines, err := fetchStationFile(TAF_PATH, station)
+		if err != nil {
+			return value, err
+		}
+		stationTaf := strings.Join(lines, "\n")
+		// the forecast is wrapped over several lines
+		taf, err := ParseTaf(strings.Join(lines, " "), getParseOptions(reference))
+		if err != nil {
+			return value, fmt.Errorf("%s: unable to decode forecast: %v", station, err)
+		}
+		if forecast || decode {
+			stationTaf += "\n" + GetDetailTaf(taf)
+		}
+		if timeline {
+			stationTaf += "\n" + GetTafTimeline(taf)
+		}
+
+		value += stationTaf + "\n"
+	}
+
+	return value, nil
+}
+
+//Fetch a NOAA station file, the first line of which is the date it was issued
+//Returns that date, or now if it can't be read, and the non-blank lines after it
+func fetchStationFile(path string, station string) (issued time.Time, lines []string, err error) {
+	resp, err := http.Get(path + station + ".TXT")
+	if err != nil {
+		return issued, lines, fmt.Errorf("%s: unable to fetch report: %v", station, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return issued, lines, fmt.Errorf("%s: unable to fetch report: %s", station, resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return issued, lines, fmt.Errorf("%s: unable to read report: %v", station, err)
+	}
+	fileLines := strings.Split(string(body), "\n")
+	issued, err = time.Parse(METAR_DATE_FORMAT, strings.TrimSpace(fileLines[0]))
+	if err != nil {
+		issued = time.Now()
+	}
+	for _, line := range fileLines[1:] {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return issued, lines, fmt.Errorf("%s: station file has no report", station)
+	}
+	return issued, lines, nil
+}
+
+//Add the stations in the given file to the catalog
+//Returns an error if the file couldn't be read
+func LoadStationList(path string) (err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("unable to open station list: %v", err)
+	}
+	defer file.Close()
+	_, err = LoadStations(file)
+	return
+}
+
+//Reformat each report read from the input, one per line, into canonical form
+//Returns the reports, or an error naming the line that couldn't be decoded
+func NormalizeMetars(input io.Reader) (value string, err error) {
+	scanner := bufio.NewScanner(input)
+	var normalized []string
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		metar, err := ParseMetar(scanner.Text(), getParseOptions(time.Now()))
+		if err != nil {
+			return value, fmt.Errorf("line %d: unable to decode report: %v", line, err)
+		}
+		normalized = append(normalized, EncodeMetar(metar))
+	}
+	if err = scanner.Err(); err != nil {
+		return value, fmt.Errorf("unable to read reports: %v", err)
+	}
+	return strings.Join(normalized, "\n"), nil
 }
 
 //Parse command-line args
@@ -92,10 +214,22 @@ func ParseArgs(arguments []string) (flag.FlagSet, bool) {
 		flag.PrintDefaults()
 		success = false
 	}
-	if len(flagSet.Args()) == 0 {
+	if len(flagSet.Args()) == 0 && !normalize {
 		fmt.Fprintln(Output, "Usage: metarg [options] station")
 		success = false
 	}
+	if pressureUnit != "" && pressureUnit != "inHg" && pressureUnit != "hPa" && pressureUnit != "Pa" {
+		fmt.Fprintln(Output, "Pressure unit must be inHg, hPa or Pa")
+		success = false
+	}
+	if _, ok := ParseUnitSystem(unitSystem); !ok {
+		fmt.Fprintln(Output, "Units must be metric, imperial, aviation or si")
+		success = false
+	}
+	if parseMode != "lenient" && parseMode != "strict" {
+		fmt.Fprintln(Output, "Parse mode must be lenient or strict")
+		success = false
+	}
 	if verbose {
 		fmt.Fprintln(Output, "Shh, not implemented yet ...")
 		success = false
@@ -104,24 +238,22 @@ func ParseArgs(arguments []string) (flag.FlagSet, bool) {
 	return *flagSet, success
 }
 
-func SearchStations(search string) (results []string, success bool) {
+func SearchStations(search string) (results []string, err error) {
 	search = strings.ToUpper(search)
 	resp, err := http.Get(METAR_LIST_REF)
 	if err != nil {
-		return
+		return results, fmt.Errorf("unable to fetch station list: %v", err)
 	}
 	defer resp.Body.Close()
 	byteBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Fprint(Output, "ERROR ", err)
-		return
+		return results, fmt.Errorf("unable to read station list: %v", err)
 	}
 	body := string(byteBody)
 	body = strings.Replace(body, "\n", "", -1)
 	stationSearch := regexp.MustCompile(`<tr><td.*?>(?P<state>\w{2})</td>` +
 		`<td.*?>(?P<name>.*?)</td><td.*?><a.*?>(?P<code>\w+)`)
 	matches := stationSearch.FindAllStringSubmatch(body, -1)
-	success = true
 	for _, match := range matches {
 		_, state, name, _ := match[0], match[1], match[2], match[3]
 		if strings.Contains(name, search) || strings.Contains(state, search) {
@@ -129,30 +261,168 @@ func SearchStations(search string) (results []string, success bool) {
 		}
 
 	}
-	return results, success
+	return results, nil
 }
 
 func GetDetailMetar(metar Metar) (details string) {
 	const stringTemplate = `Station       : {{.Station}}
 Day           : {{.Day}}
-Time          : {{.Time.Format "15:04"}} UTC
-Wind direction: {{.WindDirectionDegree}} ({{.WindDirection}})
-Wind speed    : {{.WindSpeed}} KT
-Wind gust     : {{.WindGust}} KT
-Visibility    : {{.Visibility}}
-Temperature   : {{.Temperature}} C
-Dewpoint      : {{.Dewpoint}} C
-Pressure      : {{.Pressure}} "Hg
-Clouds        : {{range .Clouds}}{{.}} ft {{end}}
-Remarks       : 
+Time          : {{.Time.Format "2006-01-02 15:04"}} UTC
+Report        : {{or .Type "METAR"}}{{if .Auto}}, automated{{end}}{{if .Corrected}}, corrected{{end}}
+{{- if .Nil}}, missing
+{{else}}
+Wind direction: {{if .WindCalm}}calm{{else if .WindVariable}}variable
+{{- else}}{{with .WindDirectionDegree}}{{.}} ({{$.WindDirection}}){{else}}not reported{{end}}{{end}}
+{{with .VariableRange}}Wind varying  : {{.}}
+{{end}}Wind speed    : {{with .WindSpeed}}{{if $.WindSpeedAbove}}above {{end}}{{.Format (speedUnit $.WindUnit)}}{{else}}not reported{{end}}
+Wind gust     : {{with .WindGust}}{{if $.WindGustAbove}}above {{end}}{{.Format (speedUnit $.WindUnit)}}{{else}}{{if .WindSpeed}}none{{else}}not reported{{end}}{{end}}
+Visibility    : {{with .Visibility}}{{.Format distanceUnit}}{{else}}not reported{{end}}
+{{range .RunwayVisualRanges}}Runway range  : {{.Format heightUnit}}
+{{end}}Weather       : {{with .Phenomena}}{{.}}{{else}}none{{end}}
+Temperature   : {{with .Temperature}}{{.Format temperatureUnit}}{{else}}not reported{{end}}
+Dewpoint      : {{with .Dewpoint}}{{.Format temperatureUnit}}{{else}}not reported{{end}}
+{{with .RelativeHumidity}}Humidity      : {{.}}
+{{end}}{{with .DewpointDepression}}Dew spread    : {{.Format temperatureUnit}}
+{{end}}{{with .WetBulb}}Wet bulb      : {{.Format temperatureUnit}}
+{{end}}{{with .HeatIndex}}Heat index    : {{.Format temperatureUnit}}
+{{end}}{{with .Humidex}}Humidex       : {{.Format temperatureUnit}}
+{{end}}{{with .WindChill}}Wind chill    : {{.Format temperatureUnit}}
+{{end}}Pressure      : {{with .Pressure}}{{.Format pressureUnit}}{{else}}not reported{{end}}
+Elevation     : {{with .Elevation}}{{.Format heightUnit}}{{else}}unknown, load a station list with -stations{{end}}
+{{with .PressureAltitude}}Pressure alt. : {{.Format heightUnit}}
+{{end}}{{with .DensityAltitude}}Density alt.  : {{.Format heightUnit}}
+{{end}}Clouds        : {{template "clouds" .Clouds}}
+{{with .CloudBase}}Cloud base    : {{.Format heightUnit}}, estimated
+{{end}}Ceiling       : {{with .Ceiling}}{{.Format heightUnit}}{{else}}{{if .CeilingUnknown}}unknown{{else}}none{{end}}{{end}}
+Category      : {{or .FlightCategory "not reported"}}
+{{with .RecentWeather}}Recent weather: {{.}}
+{{end}}{{with .WindShear}}Wind shear    : {{range $i, $runway := .}}{{if $i}}, {{end}}
+{{- if eq . "ALL"}}all runways{{else}}runway {{.}}{{end}}{{end}}
+{{end}}{{range .Trends}}Trend         : {{.Period}}{{template "conditions" .}}
+{{end}}Remarks       : 
 {{range .Remarks}}{{.}}
-{{end}}`
-	tmpl, err := template.New("metarDetail").Parse(stringTemplate)
+{{end}}{{range .Unparsed}}Unparsed      : {{.Error}}
+{{end}}{{end}}`
+	return executeTemplate("metarDetail", stringTemplate, metar)
+}
+
+// Describes the forecast, each change group on a line of its own
+func GetDetailTaf(taf Taf) (details string) {
+	const stringTemplate = `Station       : {{.Station}}
+Issued        : {{.Issued.Format "2006-01-02 15:04"}} UTC
+Forecast      : TAF{{if .Amended}}, amended{{end}}{{if .Corrected}}, corrected{{end}}
+{{- if .Nil}}, missing
+{{else}}{{if .Cancelled}}, cancelled{{end}}
+Initially     : {{.Period}}{{template "forecast" .TafForecast}}
+{{range .Temperatures}}Temperature   : {{.Format temperatureUnit}}
+{{end}}{{range .Changes}}Change        : {{.Period}}{{template "forecast" .}}
+{{end}}{{with .RawRemarks}}Remarks       : {{.}}
+{{end}}{{range .Unparsed}}Unparsed      : {{.Error}}
+{{end}}{{end}}
+{{- define "forecast"}}{{template "conditions" .}}
+{{- with .WindShear}}, wind shear at {{.Height.Format heightUnit}} {{template "wind" .Wind}}{{end}}{{end}}`
+	return executeTemplate("tafDetail", stringTemplate, taf)
+}
+
+// Lays out the forecast as a table with a row for each hour, showing the
+// prevailing groups and any that may apply instead.  The groups are shown
+// as report code, or described in the units selected with -units.
+func GetTafTimeline(taf Taf) (table string) {
+	var doc bytes.Buffer
+	writer := tabwriter.NewWriter(&doc, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(writer, "Time (UTC)\tCategory\tWind\tVisibility\tWeather\tClouds\tPossible")
+	for _, hour := range taf.Timeline() {
+		prevailing := hour.Prevailing
+		var possible []string
+		for _, change := range hour.Possible {
+			kind := change.Change.Type
+			if change.Change.Probability != 0 {
+				kind = fmt.Sprintf("PROB%d", change.Change.Probability)
+				if change.Change.Type == "TEMPO" {
+					kind += " TEMPO"
+				}
+			}
+			possible = append(possible, kind+timelineChange(change.Change))
+		}
+		wind, visibility, weather, clouds := timelineConditions(prevailing.Conditions)
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", hour.Time.Format("Jan 2 15:04"), prevailing.FlightCategory(),
+			wind, visibility, weather, clouds, strings.Join(possible, "; "))
+	}
+	writer.Flush()
+	return doc.String()
+}
+
+// The wind, visibility, weather and clouds for a row of the timeline
+func timelineConditions(conditions Conditions) (wind, visibility, weather, clouds string) {
+	if unitSystem == "" {
+		return strings.Join(encodeWind(conditions.Wind), " "), strings.Join(encodeVisibility(conditions.Visibility), " "),
+			strings.Join(encodeWeather(conditions.Phenomena, ""), " "), strings.Join(encodeClouds(conditions.Clouds), " ")
+	}
+	if conditions.WindUnit != "" {
+		wind = executeTemplate("timelineWind", `{{template "wind" .}}`, conditions.Wind)
+	}
+	if conditions.Visibility != nil {
+		visibility = conditions.Visibility.Format(displayUnits().Distance)
+	}
+	weather = conditions.Phenomena.String()
+	clouds = executeTemplate("timelineClouds", `{{template "clouds" .}}`, conditions.Clouds)
+	return
+}
+
+// The groups of a change that may apply, to follow its type in the timeline
+func timelineChange(change TafForecast) string {
+	if unitSystem == "" {
+		groups := encodeConditions(change.Conditions, change.NoSignificantWeather)
+		return strings.TrimRight(" "+strings.Join(groups, " "), " ")
+	}
+	return strings.TrimPrefix(executeTemplate("timelineChange", `{{template "conditions" .}}`, change), ",")
+}
+
+// Definitions shared by the detail templates
+const conditionsTemplates = `
+{{- define "conditions"}}
+{{- if .WindUnit}}, wind {{template "wind" .Wind}}{{end}}
+{{- with .Visibility}}, visibility {{.Format distanceUnit}}{{end}}
+{{- with .Phenomena}}, {{.}}{{end}}
+{{- if .NoSignificantWeather}}, no significant weather{{end}}
+{{- with .Clouds}}, clouds {{template "clouds" .}}{{end}}{{end}}
+{{- define "clouds"}}{{range $i, $layer := .}}{{if $i}}, {{end}}{{if .Clear}}{{.Coverage}}
+{{- else if .VerticalVisibility}}vertical visibility {{with .Base}}{{.Format heightUnit}}{{else}}not reported{{end}}
+{{- else}}{{if eq .Coverage "///"}}unknown coverage{{else}}{{.Coverage}}{{end}} at {{with .Base}}{{.Format heightUnit}}{{else}}unknown height{{end}}{{with .Convective}} {{.}}{{end}}{{end}}{{end}}{{end}}
+{{- define "wind"}}{{if .WindCalm}}calm{{else}}{{if .WindVariable}}variable{{else}}{{.WindDirectionDegree}} ({{.WindDirection}}){{end}}
+{{- ""}} at {{with .WindSpeed}}{{.Format (speedUnit $.WindUnit)}}{{else}}unknown speed{{end}}
+{{- with .WindGust}} gusting {{.Format (speedUnit $.WindUnit)}}{{end}}{{end}}{{end}}`
+
+// The units selected on the command line
+func displayUnits() Units {
+	return UnitSystem(unitSystem).Units()
+}
+
+// Renders a detail template along with the shared definitions
+func executeTemplate(name string, stringTemplate string, data any) (details string) {
+	funcs := template.FuncMap{
+		"temperatureUnit": func() string { return displayUnits().Temperature },
+		"distanceUnit":    func() string { return displayUnits().Distance },
+		"heightUnit":      func() string { return displayUnits().Height },
+		"speedUnit": func(reported string) string {
+			if unit := displayUnits().Speed; unit != "" {
+				return unit
+			}
+			return reported
+		},
+		"pressureUnit": func() string {
+			if pressureUnit != "" {
+				return pressureUnit
+			}
+			return displayUnits().Pressure
+		},
+	}
+	tmpl, err := template.New(name).Funcs(funcs).Parse(stringTemplate + conditionsTemplates)
 	if err != nil {
 		panic(err)
 	}
 	var doc bytes.Buffer
-	err = tmpl.Execute(&doc, metar)
+	err = tmpl.Execute(&doc, data)
 	if err != nil {
 		panic(err)
 	}
@@ -160,11 +430,20 @@ Remarks       :
 	return
 }
 
-func DecodeMetar(metarLine string) (details string, success bool) {
-	metar, success := ParseMetar(metarLine)
-	if !success {
-		return details, success
+func DecodeMetar(metarLine string, options ParseOptions) (details string, err error) {
+	metar, err := ParseMetar(metarLine, options)
+	if err != nil {
+		return details, err
 	}
 	details = GetDetailMetar(metar)
 	return
 }
+
+func DecodeTaf(tafText string, options ParseOptions) (details string, err error) {
+	taf, err := ParseTaf(tafText, options)
+	if err != nil {
+		return details, err
+	}
+	details = GetDetailTaf(taf)
+	return
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"os"
+	"strings"
 	"testing"
 )
 
@@ -29,6 +31,88 @@ func TestParseArgsBasic(t *testing.T) {
 	}
 }
 
+func TestParseArgsPressureUnit(t *testing.T) {
+	args := []string{"-p", "hPa", "KPKW"}
+	_, success := ParseArgs(args)
+	if !success {
+		t.Error("Failed to parse but should've succeeded")
+	}
+	if pressureUnit != "hPa" {
+		t.Error("Pressure unit should be hPa")
+	}
+	metar, _ := ParseMetar("KPKW 210051Z 15007KT 10SM OVC060 05/01 A2992", testOptions)
+	details := GetDetailMetar(metar)
+	if !strings.Contains(details, "Pressure      : 1013 hPa\n") {
+		t.Errorf("Pressure not shown in hPa: %v", details)
+	}
+	pressureUnit = ""
+}
+
+func TestParseArgsParseMode(t *testing.T) {
+	args := []string{"-m", "strict", "KPKW"}
+	_, success := ParseArgs(args)
+	if !success {
+		t.Error("Failed to parse but should've succeeded")
+	}
+	if parseMode != "strict" {
+		t.Error("Parse mode should be strict")
+	}
+	parseMode = "lenient"
+}
+
+func TestParseArgsForecast(t *testing.T) {
+	args := []string{"-t", "-d", "KPKW"}
+	_, success := ParseArgs(args)
+	if !success {
+		t.Error("Failed to parse but should've succeeded")
+	}
+	if !forecast {
+		t.Error("Forecast should be true")
+	}
+	forecast = false
+}
+
+func TestParseArgsUnits(t *testing.T) {
+	args := []string{"-units", "metric", "KPKW"}
+	_, success := ParseArgs(args)
+	if !success {
+		t.Error("Failed to parse but should've succeeded")
+	}
+	if unitSystem != "metric" {
+		t.Error("Units should be metric")
+	}
+	metar, _ := ParseMetar("KPKW 210051Z 15010G20KT 1 1/2SM R28L/2400FT BR OVC006 05/01 A2992 RMK SLP134 4/012",
+		getParseOptions(testOptions.Reference))
+	details := GetDetailMetar(metar)
+	t.Logf("Details: %v", details)
+	for _, expected := range []string{
+		"Wind speed    : 18.5 KMH\n",
+		"Wind gust     : 37 KMH\n",
+		"Visibility    : 2414 meters\n",
+		"Runway range  : runway 28L 732 meters\n",
+		"Temperature   : 5 °C\n",
+		"Pressure      : 1013 hPa\n",
+		"Clouds        : OVC at 182.9 m\n",
+		"Ceiling       : 182.9 m\n",
+		"Sea level pressure 1013.4 hPa\n",
+		"Snow coverage:  304.8 mm\n",
+	} {
+		if !strings.Contains(details, expected) {
+			t.Errorf("Details missing %q", expected)
+		}
+	}
+	unitSystem = ""
+	var usage strings.Builder
+	Output = &usage
+	args = []string{"-units", "furlongs", "KPKW"}
+	_, success = ParseArgs(args)
+	if success || !strings.Contains(usage.String(), "Units must be") {
+		t.Error("Parsed unknown units but should've failed")
+	}
+	Output = os.Stdout
+	unitSystem = ""
+}
+
 //TODO make these run without writing to stdout... annoying
 //func TestParseArgsInvalid(t *testing.T) {
 //	args := []string{"-wrong", "KPKW"}
@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 // Regexp wrapper
@@ -15,11 +16,78 @@ type MappableRegexp struct {
 }
 
 type Metar struct {
-	Station, Phenomena, Visibility, WindDirection                             string
-	Clouds, Remarks                                                           []string
-	Time                                                                      time.Time
-	WindSpeed, WindGust, Temperature, Dewpoint, Pressure, WindDirectionDegree float32
-	Day                                                                       int32
+	Conditions
+	Type               string // METAR or SPECI, empty if the report didn't say
+	Auto, Corrected    bool
+	Nil                bool // the report is missing
+	Station            string
+	RunwayVisualRanges []RunwayVisualRange
+	RecentWeather      Phenomena
+	WindShear          []string // runway designators, or ALL for all runways
+	Trends             []Trend
+	Remarks            []string
+	WeatherEvents      []WeatherEvent // when weather began or ended, from the remarks
+	PeakWind           *PeakWind      // from the remarks, as are the values below
+	WindShift          *WindShift
+	VisibilityRange    *VisibilityRange
+	CeilingRange       *CeilingRange
+	TowerVisibility    *Visibility
+	SurfaceVisibility  *Visibility
+	OtherVisibilities  []LocationVisibility
+	LocatedPhenomena   []LocatedPhenomenon
+	RawRemarks         string       // the text after RMK, as reported
+	Unparsed           []ParseError // tokens passed over in lenient mode
+	Time               time.Time
+	Temperature        *Temperature // nil when not reported
+	Dewpoint           *Temperature
+	Pressure           *Pressure
+	Day                int32
+}
+
+// Weather conditions common to observations and forecasts
+type Conditions struct {
+	Wind
+	Visibility *Visibility // nil when not reported
+	Phenomena  Phenomena
+	Clouds     []CloudLayer
+}
+
+// Surface wind, along with the range it varies over if one was reported.
+// Values that weren't reported are nil: the direction of a variable wind,
+// the gust when there isn't one, or everything when there's no wind group.
+type Wind struct {
+	WindDirection, WindUnit                               string
+	WindSpeed, WindGust                                   *Speed
+	WindDirectionDegree                                   *float32
+	WindVariableFrom, WindVariableTo                      *float32
+	WindCalm, WindVariable, WindSpeedAbove, WindGustAbove bool
+}
+
+// Describes the range the wind direction varies over, e.g. "180 (S) to 240 (WSW)"
+func (this Wind) VariableRange() string {
+	if this.WindVariableFrom == nil || this.WindVariableTo == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v (%s) to %v (%s)",
+		*this.WindVariableFrom, compass.GetCompassAbbreviation(*this.WindVariableFrom),
+		*this.WindVariableTo, compass.GetCompassAbbreviation(*this.WindVariableTo))
+}
+
+// A single sky-condition layer, or a clear-sky report like SKC or NCD
+type CloudLayer struct {
+	Coverage           string  // FEW, SCT, BKN, OVC, VV, SKC, CLR, NSC, NCD or /// if not reported
+	Base               *Height // above ground level, nil for clear skies
+	Convective         string  // CB, TCU or ""
+	VerticalVisibility bool    // an indefinite ceiling, Base being the vertical visibility
+}
+
+// Reports whether the layer is a clear-sky report with no base height
+func (this CloudLayer) Clear() bool {
+	switch this.Coverage {
+	case "SKC", "CLR", "NSC", "NCD":
+		return true
+	}
+	return false
 }
 
 // Returns a map of named groups to values from the given input string
@@ -33,91 +101,491 @@ func (this *MappableRegexp) GetMap(input string) (result map[string]string) {
 	return
 }
 
-func ParseMetar(flatMetar string) (metar Metar, success bool) {
-	mappable := MappableRegexp{*(regexp.MustCompile(
-		`^(?P<station>\w{4})\s(?P<time>\w{7})\s(?P<auto>AUTO\s)?(?P<wind>\w+)\s(?P<weather>\w+\s)?(?P<visibility>\S+[SK]M)` +
-			`\s(?P<clouds>(\D\D\D\d?\d?\d?\s?)+)\s(?P<tempdue>M?\d\d\/M?\d\d)\s(?P<pressure>A\d{4})\sRMK(?P<remarks>.*)`))}
-	matches := mappable.GetMap(flatMetar)
-	if len(matches) < 7 {
-		return metar, false
-	}
-	metar.Station = matches["station"]
-	metar.Day, metar.Time = parseDayTime(matches["time"])
-
-	metar.WindDirection, metar.WindSpeed,
-		metar.WindDirectionDegree, metar.WindGust = parseWind(matches["wind"])
-	metar.Visibility = parseVisibility(matches["visibility"])
-	metar.Clouds = parseClouds(matches["clouds"])
-	metar.Temperature, metar.Dewpoint = parseTempDew(matches["tempdue"])
-	metar.Pressure = parsePressure(matches["pressure"])
-	metar.Remarks = parseRemarks(matches["remarks"])
-	return metar, true
-}
-
-func parseWind(windFlat string) (direction string, speed float32,
-	dirDegrees float32, gust float32) {
-	mapRegex := MappableRegexp{*regexp.MustCompile(`(\d{3})(\d+)(G(\d+))?KT`)}
-	match := mapRegex.FindStringSubmatch(windFlat)
-	dirDegrees64, _ := strconv.ParseInt(match[1], 10, 32)
-	speed64, _ := strconv.ParseFloat(match[2], 32)
-	dirDegrees = float32(dirDegrees64)
-	direction = compass.GetCompassAbbreviation(dirDegrees)
-	speed = float32(speed64)
-	if match[4] != "" {
-		gust64, _ := strconv.ParseFloat(match[4], 32)
-		gust = float32(gust64)
-	} else {
-		gust = speed
+// A recognizable group within a report.  The pattern loosely identifies
+// which group a token belongs to, and the format is what the token must
+// look like for that group to be decoded into the target.
+type metarGroup[T any] struct {
+	name    string
+	pattern *regexp.Regexp
+	format  *regexp.Regexp
+	repeats bool
+	decode  func(target *T, value string)
+}
+
+// A whitespace-delimited piece of a raw report and its byte offset
+type metarToken struct {
+	value  string
+	offset int
+}
+
+// Groups in the order they appear in a report
+var metarGroups = []metarGroup[Metar]{
+	{"report type", regexp.MustCompile(`^(METAR|SPECI)$`),
+		regexp.MustCompile(`^(METAR|SPECI)$`), false, (*Metar).decodeType},
+	{"modifier", regexp.MustCompile(`^COR$`),
+		regexp.MustCompile(`^COR$`), false, (*Metar).decodeModifier},
+	{"station", regexp.MustCompile(`^[A-Z][A-Z0-9]{3}$`),
+		regexp.MustCompile(`^[A-Z][A-Z0-9]{3}$`), false, (*Metar).decodeStation},
+	{"time", regexp.MustCompile(`^\d+Z$`),
+		dayTimeRegex, false, (*Metar).decodeTime},
+	{"modifier", regexp.MustCompile(`^(AUTO|COR)$`),
+		regexp.MustCompile(`^(AUTO|COR)$`), true, (*Metar).decodeModifier},
+	{"missing report", regexp.MustCompile(`^NIL$`),
+		regexp.MustCompile(`^NIL$`), false, (*Metar).decodeNil},
+	{"wind", regexp.MustCompile(`^[\w\/]*(KT|MPS|KMH)$`),
+		windRegex, false, (*Metar).decodeWind},
+	{"wind variation", regexp.MustCompile(`^\d+V\d+$`),
+		regexp.MustCompile(`^\d{3}V\d{3}$`), false, (*Metar).decodeWindVariation},
+	{"visibility", regexp.MustCompile(`^(.+[SK]M|\d{4}(NDV)?|CAVOK|\/{4})$`),
+		visibilityRegex, false, (*Metar).decodeVisibility},
+	{"directional visibility", regexp.MustCompile(`^\d{4}[NESW]{1,2}$`),
+		directionalVisibilityRegex, true, (*Metar).decodeDirectionalVisibility},
+	{"runway visual range", regexp.MustCompile(`^R\d\d\w?\/`),
+		runwayVisualRangeRegex, true, (*Metar).decodeRunwayVisualRange},
+	// a leading RE is recent weather, further along
+	{"weather", regexp.MustCompile(`^([-+]|VC)?([A-QS-Z][A-Z]|R[A-DF-Z])([A-Z]{2})*$`),
+		weatherRegex, true, (*Metar).decodeWeather},
+	{"clouds", regexp.MustCompile(`^(FEW|SCT|BKN|OVC|VV|\/\/\/|SKC|CLR|NSC|NCD)`),
+		cloudLayerRegex, true, (*Metar).decodeClouds},
+	{"temp/dew", regexp.MustCompile(`^(M?\d+|\/\/)\/`),
+		tempDewRegex, false, (*Metar).decodeTempDew},
+	{"altimeter", regexp.MustCompile(`^[AQ](\d+|\/+)$`),
+		regexp.MustCompile(`^[AQ](\d{4}|\/{4})$`), true, (*Metar).decodePressure},
+	{"recent weather", regexp.MustCompile(`^RE[A-Z]{2,}$`),
+		recentWeatherRegex, true, (*Metar).decodeRecentWeather},
+	{"wind shear", regexp.MustCompile(`^WS `),
+		windShearRegex, true, (*Metar).decodeWindShear},
+}
+
+// Groups that span more than one token, which are joined back together
+// before decoding: 1 1/2SM, WS R27, WS ALL RWY
+var joinedGroups = [][]*regexp.Regexp{
+	{regexp.MustCompile(`^\d$`), regexp.MustCompile(`^\d\/\d+SM$`)},
+	{regexp.MustCompile(`^WS$`), regexp.MustCompile(`^ALL$`), regexp.MustCompile(`^RWY$`)},
+	{regexp.MustCompile(`^WS$`), regexp.MustCompile(`^(R|RWY)\d{2}[LCR]?$`)},
+}
+
+// Splits a raw report into tokens, keeping track of where each one starts
+func tokenize(flatMetar string) (tokens []metarToken) {
+	return joinTokens(flatMetar, splitTokens(flatMetar), joinedGroups)
+}
+
+// Splits text on whitespace, keeping track of where each token starts
+func splitTokens(flat string) (tokens []metarToken) {
+	regex := regexp.MustCompile(`\S+`)
+	for _, bounds := range regex.FindAllStringIndex(flat, -1) {
+		tokens = append(tokens, metarToken{flat[bounds[0]:bounds[1]], bounds[0]})
+	}
+	return
+}
+
+// Joins runs of tokens that make up a single group
+func joinTokens(flatMetar string, tokens []metarToken, groups [][]*regexp.Regexp) (joined []metarToken) {
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		for _, patterns := range groups {
+			if matchesSequence(tokens[i:], patterns) {
+				last := tokens[i+len(patterns)-1]
+				token.value = flatMetar[token.offset : last.offset+len(last.value)]
+				i += len(patterns) - 1
+				break
+			}
+		}
+		joined = append(joined, token)
+	}
+	return
+}
+
+// Reports whether the tokens start with a run matching each pattern in turn
+func matchesSequence(tokens []metarToken, patterns []*regexp.Regexp) bool {
+	if len(tokens) < len(patterns) {
+		return false
+	}
+	for i, pattern := range patterns {
+		if !pattern.MatchString(tokens[i].value) {
+			return false
+		}
+	}
+	return true
+}
+
+// How ParseMetar treats tokens that don't fit the format
+type ParseMode int
+
+const (
+	// Tokens that aren't recognized, or look like a group but can't be
+	// decoded as one, are collected into Metar.Unparsed
+	Lenient ParseMode = iota
+	// Any token that isn't a well-formed group in its place fails the parse
+	Strict
+)
+
+type ParseOptions struct {
+	Mode ParseMode
+	// A time shortly after the observation, such as now or the date the
+	// report was retrieved, which fills in the month and year.  Zero
+	// means now.
+	Reference time.Time
+	// The units remarks are translated into
+	Units UnitSystem
+}
+
+// The reference time, defaulting to now
+func (this ParseOptions) reference() time.Time {
+	if this.Reference.IsZero() {
+		return time.Now()
+	}
+	return this.Reference
+}
+
+// Walks the report one token at a time, decoding each group it recognizes.
+// Groups may be missing or repeated.  How unrecognized or malformed tokens
+// are handled depends on the mode; a failed parse returns a *ParseError
+// along with whatever was decoded before it.
+func ParseMetar(flatMetar string, options ParseOptions) (metar Metar, err error) {
+	// the = terminating a report in a bulletin isn't part of any group
+	flatMetar = strings.TrimRight(strings.TrimRightFunc(flatMetar, unicode.IsSpace), "=")
+	tokens := tokenize(flatMetar)
+	var remarks *metarToken
+	for i, token := range tokens {
+		if token.value == "RMK" {
+			remarks = &tokens[i]
+			tokens = tokens[:i]
+			break
+		}
+	}
+
+	sections := splitTrends(tokens)
+	metar.Unparsed, err = decodeGroups(&metar, metarGroups, sections[0], options.Mode)
+	if err != nil {
+		return metar, err
+	}
+	if metar.Station == "" {
+		return metar, &ParseError{"station", "", 0, "no station identifier found"}
+	}
+	if metar.Day == 0 {
+		return metar, &ParseError{"time", "", 0, "no observation time found"}
+	}
+	var resolved bool
+	if metar.Time, resolved = resolveDayTime(metar.Day, metar.Time, options.reference()); !resolved {
+		return metar, &ParseError{"time", "", 0, fmt.Sprintf("no recent month has a day %d", metar.Day)}
+	}
+	for _, section := range sections[1:] {
+		trend, unparsed, err := parseTrend(section, metar.Time, options.Mode)
+		metar.Trends = append(metar.Trends, trend)
+		metar.Unparsed = append(metar.Unparsed, unparsed...)
+		if err != nil {
+			return metar, err
+		}
+	}
+
+	if remarks != nil {
+		remarksFlat := flatMetar[remarks.offset+len(remarks.value):]
+		if strings.TrimSpace(remarksFlat) == "" {
+			remarksErr := ParseError{"remarks", remarks.value, remarks.offset, "no remarks follow RMK"}
+			if options.Mode == Strict {
+				return metar, &remarksErr
+			}
+			metar.Unparsed = append(metar.Unparsed, remarksErr)
+		}
+		metar.RawRemarks = strings.TrimSpace(remarksFlat)
+		metar.decodeRemarks(remarksFlat, options.Units.Units())
+		metar.refineTempDew()
+	}
+	return metar, nil
+}
+
+// Stands in for a whole group an automated station couldn't report, e.g.
+// // or /////, or a runway visual range from a failed sensor, e.g. R06///////
+var placeholderRegex = regexp.MustCompile(`^(M|\/+|R\d{2}[LCR]?\/+)$`)
+
+// Decodes each token into the target using the first group, no earlier
+// than the last one decoded, whose pattern it fits.  Placeholders for
+// unreported groups are passed over, leaving those fields nil.  Tokens
+// that fit no group, or fit one but are malformed, are returned in
+// lenient mode and fail the decode in strict mode.
+func decodeGroups[T any](target *T, groups []metarGroup[T], tokens []metarToken,
+	mode ParseMode) (unparsed []ParseError, err error) {
+	next := 0
+	for _, token := range tokens {
+		if placeholderRegex.MatchString(token.value) {
+			continue
+		}
+		failure := &ParseError{"", token.value, token.offset, "unrecognized group"}
+		for i := next; i < len(groups); i++ {
+			group := groups[i]
+			if !group.pattern.MatchString(token.value) {
+				continue
+			}
+			if !group.format.MatchString(token.value) {
+				failure = &ParseError{group.name, token.value, token.offset, "malformed group"}
+				break
+			}
+			failure = nil
+			if group.decode != nil {
+				group.decode(target, token.value)
+			}
+			if group.repeats {
+				next = i
+			} else {
+				next = i + 1
+			}
+			break
+		}
+		if failure == nil {
+			continue
+		}
+		if mode == Strict {
+			return unparsed, failure
+		}
+		unparsed = append(unparsed, *failure)
+	}
+	return unparsed, nil
+}
+
+func (this *Metar) decodeType(value string) {
+	this.Type = value
+}
+
+func (this *Metar) decodeModifier(value string) {
+	this.Auto = this.Auto || value == "AUTO"
+	this.Corrected = this.Corrected || value == "COR"
+}
+
+func (this *Metar) decodeNil(value string) {
+	this.Nil = true
+}
+
+func (this *Metar) decodeStation(value string) {
+	this.Station = value
+}
+
+// The format has already checked the day and time are in range
+func (this *Metar) decodeTime(value string) {
+	this.Day, this.Time, _ = parseDayTime(value)
+}
+
+func (this *Conditions) decodeWind(value string) {
+	this.Wind = parseWind(value)
+}
+
+func (this *Conditions) decodeWindVariation(value string) {
+	from, to := parseWindVariation(value)
+	this.WindVariableFrom, this.WindVariableTo = &from, &to
+}
+
+func (this *Conditions) decodeVisibility(value string) {
+	if strings.HasPrefix(value, "/") {
+		this.Visibility = nil
+		return
+	}
+	visibility := parseVisibility(value)
+	this.Visibility = &visibility
+}
+
+func (this *Conditions) decodeDirectionalVisibility(value string) {
+	if this.Visibility == nil {
+		this.Visibility = &Visibility{}
+	}
+	this.Visibility.Directional = append(this.Visibility.Directional, parseDirectionalVisibility(value))
+}
+
+func (this *Metar) decodeRunwayVisualRange(value string) {
+	this.RunwayVisualRanges = append(this.RunwayVisualRanges, parseRunwayVisualRange(value))
+}
+
+func (this *Conditions) decodeWeather(value string) {
+	this.Phenomena = append(this.Phenomena, parseWeather(value))
+}
+
+func (this *Conditions) decodeClouds(value string) {
+	this.Clouds = append(this.Clouds, parseCloudLayer(value))
+}
+
+func (this *Metar) decodeTempDew(value string) {
+	this.Temperature, this.Dewpoint = parseTempDew(value)
+}
+
+// The hourly T group in the remarks gives the temperature and dew point
+// to a tenth of a degree, more precisely than the body
+func (this *Metar) refineTempDew() {
+	for _, remark := range strings.Fields(this.RawRemarks) {
+		if temperature, dewpoint := parsePreciseTempDew(remark); temperature != nil {
+			this.Temperature = temperature
+			if dewpoint != nil {
+				this.Dewpoint = dewpoint
+			}
+			return
+		}
+	}
+}
+
+// A report may carry both an A and a Q group; keep whichever was first as the reported unit
+func (this *Metar) decodePressure(value string) {
+	if strings.HasSuffix(value, "/") {
+		return
+	}
+	pressure := parsePressure(value)
+	if this.Pressure != nil {
+		pressure.Unit = this.Pressure.Unit
+		if pressure.InHg == 0 {
+			pressure.InHg = this.Pressure.InHg
+		}
+		if pressure.HPa == 0 {
+			pressure.HPa = this.Pressure.HPa
+		}
+	}
+	this.Pressure = &pressure
+}
+
+func (this *Metar) decodeRecentWeather(value string) {
+	this.RecentWeather = append(this.RecentWeather, parseWeather(value[2:]))
+}
+
+func (this *Metar) decodeWindShear(value string) {
+	this.WindShear = append(this.WindShear, parseWindShear(value))
+}
+
+var windRegex = regexp.MustCompile(`^(?P<direction>\d{3}|VRB|\/{3})(?P<speed>P?\d{2,3}|\/{2})` +
+	`(G(?P<gust>P?\d{2,3}))?(?P<unit>KT|MPS|KMH)$`)
+
+func parseWind(windFlat string) (wind Wind) {
+	mappable := MappableRegexp{*windRegex}
+	matches := mappable.GetMap(windFlat)
+	wind.WindUnit = matches["unit"]
+	if matches["speed"] != "//" {
+		speed, above := parseWindSpeed(matches["speed"], wind.WindUnit)
+		wind.WindSpeed, wind.WindSpeedAbove = &speed, above
+	}
+	if matches["gust"] != "" {
+		gust, above := parseWindSpeed(matches["gust"], wind.WindUnit)
+		wind.WindGust, wind.WindGustAbove = &gust, above
+	}
+	switch matches["direction"] {
+	case "VRB":
+		wind.WindVariable = true
+		wind.WindDirection = "variable"
+		return
+	case "///":
+		return
 	}
+	dirDegrees64, _ := strconv.ParseInt(matches["direction"], 10, 32)
+	wind.WindDirectionDegree = optional(float32(dirDegrees64))
+	if *wind.WindDirectionDegree == 0 && wind.WindSpeed != nil && *wind.WindSpeed == 0 {
+		wind.WindCalm = true
+		wind.WindDirection = "calm"
+		return
+	}
+	wind.WindDirection = compass.GetCompassAbbreviation(*wind.WindDirectionDegree)
+	return
+}
+
+// Parses a speed like 05, 105 or P99 in the given unit, the P meaning "greater than"
+func parseWindSpeed(speedFlat string, unit string) (speed Speed, above bool) {
+	above = strings.HasPrefix(speedFlat, "P")
+	speed64, _ := strconv.ParseFloat(strings.TrimPrefix(speedFlat, "P"), 32)
+	return speedIn(float32(speed64), unit), above
+}
+
+// Parses the variable-direction group, e.g. 180V240
+func parseWindVariation(variationFlat string) (from float32, to float32) {
+	regex := regexp.MustCompile(`^(\d{3})V(\d{3})$`)
+	matches := regex.FindStringSubmatch(variationFlat)[1:]
+	from = parseSignedFloat(matches[0])
+	to = parseSignedFloat(matches[1])
 	return
 }
 
-// returns a string (for now at least) since values can be 1/2, etc.
-func parseVisibility(visibilityFlat string) (metarVisibility string) {
-	regex := regexp.MustCompile(`(.+)([SK]M)`)
-	match := regex.FindStringSubmatch(visibilityFlat)
-	metarVisibility = match[1]
-	unit := match[2]
-	switch unit {
-	case "SM":
-		unit = "miles"
-	case "KM":
-		unit = "kilometers"
+var windShearRegex = regexp.MustCompile(`^WS (ALL RWY|R(WY)?(?P<runway>\d{2}[LCR]?))$`)
+
+// Parses a wind shear group into the runway it affects, or ALL for all runways
+func parseWindShear(windShearFlat string) (runway string) {
+	mappable := MappableRegexp{*windShearRegex}
+	runway = mappable.GetMap(windShearFlat)["runway"]
+	if runway == "" {
+		runway = "ALL"
 	}
-	metarVisibility += " " + unit
 	return
 }
 
-func parseDayTime(timeFlat string) (day int32, metarTime time.Time) {
-	var day64 int64
-	var timeString string
-	regex := regexp.MustCompile(`(\d{2})(\d{4})Z`)
-	match := regex.FindStringSubmatch(timeFlat)
-	day64, _ = strconv.ParseInt(match[1], 10, 32)
-	timeString = match[2]
+// A day of the month and a time of day, e.g. 210051Z
+var dayTimeRegex = regexp.MustCompile(`^(0[1-9]|[12]\d|3[01])(([01]\d|2[0-3])[0-5]\d)Z$`)
+
+// Parses a day of the month and a time of day, failing if either is out of range
+func parseDayTime(timeFlat string) (day int32, metarTime time.Time, err error) {
+	match := dayTimeRegex.FindStringSubmatch(timeFlat)
+	if match == nil {
+		return 0, metarTime, fmt.Errorf("%q isn't a valid day and time", timeFlat)
+	}
+	day64, _ := strconv.ParseInt(match[1], 10, 32)
 	day = int32(day64)
-	metarTime, _ = time.Parse("1504", timeString)
+	metarTime, err = time.Parse("1504", match[2])
 	return
 }
 
-func parseCloudDescription(cloudFlat string) (cloud string) {
-	regex := regexp.MustCompile(`(?P<code>\D\D\D)(?P<altitude>\d\d\d)`)
-	matches := regex.FindStringSubmatch(cloudFlat)[1:]
-	alt64, _ := strconv.ParseInt(matches[1], 10, 64)
-	cloud = fmt.Sprintf("%v at %v", matches[0], alt64*100)
+// How far the observation may be after the reference, allowing for clocks that disagree
+const REFERENCE_SLACK = time.Hour
+
+// Resolves a day of the month and time of day into the latest UTC time
+// that isn't after the reference, rolling back over months and years.
+// Fails if none of the months around the reference has the day.
+func resolveDayTime(day int32, clock time.Time, reference time.Time) (resolved time.Time, ok bool) {
+	reference = reference.UTC().Add(REFERENCE_SLACK)
+	// start a month ahead in case the reference is just before midnight at the end of a month
+	for months := 1; months > -12; months-- {
+		resolved = time.Date(reference.Year(), reference.Month()+time.Month(months), int(day),
+			clock.Hour(), clock.Minute(), 0, 0, time.UTC)
+		if resolved.Day() == int(day) && !resolved.After(reference) {
+			return resolved, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Resolves a time of day into the first UTC time at or after the start time
+func resolveTimeOfDay(clock time.Time, start time.Time) (resolved time.Time) {
+	resolved = time.Date(start.Year(), start.Month(), start.Day(), clock.Hour(), clock.Minute(), 0, 0, time.UTC)
+	if resolved.Before(start) {
+		resolved = resolved.AddDate(0, 0, 1)
+	}
 	return
+}
+
+var cloudLayerRegex = regexp.MustCompile(`^((?P<coverage>FEW|SCT|BKN|OVC|VV|\/\/\/)(?P<base>\d{3}|\/\/\/)` +
+	`(?P<convective>CB|TCU|\/\/\/)?|(?P<clear>SKC|CLR|NSC|NCD))$`)
 
+func parseCloudLayer(cloudFlat string) (layer CloudLayer) {
+	mappable := MappableRegexp{*cloudLayerRegex}
+	matches := mappable.GetMap(cloudFlat)
+	if matches["clear"] != "" {
+		layer.Coverage = matches["clear"]
+		return
+	}
+	layer.Coverage = matches["coverage"]
+	if matches["base"] != "///" {
+		layer.Base = optional(Height(parseSignedFloat(matches["base"]) * 100))
+	}
+	if matches["convective"] != "///" {
+		layer.Convective = matches["convective"]
+	}
+	layer.VerticalVisibility = layer.Coverage == "VV"
+	return
 }
 
-func parseClouds(cloudFlat string) (clouds []string) {
-	regex := regexp.MustCompile(`\D\D\D\d\d\d`)
-	matches := regex.FindAllString(cloudFlat, -1)
-	for _, match := range matches {
-		clouds = append(clouds, parseCloudDescription(match))
+func parseClouds(cloudFlat string) (clouds []CloudLayer) {
+	for _, layerFlat := range strings.Fields(cloudFlat) {
+		if cloudLayerRegex.MatchString(layerFlat) {
+			clouds = append(clouds, parseCloudLayer(layerFlat))
+		}
 	}
 	return
 }
 
+// Returns a pointer to the value, for fields that may not be reported
+func optional[T any](value T) *T {
+	return &value
+}
+
 func parseSignedFloat(mBasedValue string) (signedFloat float32) {
 	var signedFloat64 float64
 	if strings.Index(mBasedValue, "M") == 0 {
@@ -129,26 +597,47 @@ func parseSignedFloat(mBasedValue string) (signedFloat float32) {
 	return float32(signedFloat64)
 }
 
-func parseTempDew(tempDueFlat string) (temperature float32, dewPoint float32) {
-	regex := regexp.MustCompile(`(M?\d\d)\/(M?\d\d)`)
-	matches := regex.FindStringSubmatch(tempDueFlat)[1:]
-	temperature = parseSignedFloat(matches[0])
-	dewPoint = parseSignedFloat(matches[1])
+var tempDewRegex = regexp.MustCompile(`^(M?\d\d|\/\/)\/(M?\d\d|\/\/)?$`)
+
+// Parses the temperature and dew point, either of which may be slashes or left off
+func parseTempDew(tempDueFlat string) (temperature *Temperature, dewPoint *Temperature) {
+	matches := tempDewRegex.FindStringSubmatch(tempDueFlat)[1:]
+	if matches[0] != "//" {
+		temperature = optional(Temperature(parseSignedFloat(matches[0])))
+	}
+	if matches[1] != "//" && matches[1] != "" {
+		dewPoint = optional(Temperature(parseSignedFloat(matches[1])))
+	}
 	return
 }
 
-func parsePressure(pressureFlat string) (pressure float32) {
-	regex := regexp.MustCompile(`A(\d{4})`)
+// Parses an altimeter setting in inches of mercury (A2992) or hectopascals (Q1013)
+func parsePressure(pressureFlat string) (pressure Pressure) {
+	regex := regexp.MustCompile(`([AQ])(\d{4})`)
 	matches := regex.FindStringSubmatch(pressureFlat)[1:]
-	pressure = parseSignedFloat(matches[0]) / 100
+	if matches[0] == "Q" {
+		pressure.HPa = parseSignedFloat(matches[1])
+		pressure.Unit = "hPa"
+	} else {
+		pressure.InHg = parseSignedFloat(matches[1]) / 100
+		pressure.Unit = "inHg"
+	}
 	return
 }
 
-func parseRemarks(remarksFlat string) (translations []string) {
-	regex := regexp.MustCompile(`\S{2,}`)
-	remarks := regex.FindAllString(remarksFlat, -1)
-	for _, remark := range remarks {
-		translations = append(translations, parseRemark(remark))
+// Translates each remark, looking ahead for remarks that span several
+// tokens and keeping those with structured values on the metar
+func (this *Metar) decodeRemarks(remarksFlat string, units Units) {
+	tokens := joinTokens(remarksFlat, splitTokens(remarksFlat), remarkJoinedGroups)
+	for i := 0; i < len(tokens); i++ {
+		if group, values, count := matchRemarkGroup(tokens[i:]); count > 0 {
+			this.Remarks = append(this.Remarks, group.decode(this, values, units))
+			i += count - 1
+			continue
+		}
+		// stray single characters aren't remarks
+		if remark := tokens[i].value; len(remark) > 1 || remark == "$" {
+			this.Remarks = append(this.Remarks, parseRemark(remark, units))
+		}
 	}
-	return
 }
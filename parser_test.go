@@ -1,7 +1,10 @@
 package main
 
 import (
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 type MetarTestScenario struct {
@@ -9,9 +12,16 @@ type MetarTestScenario struct {
 	ExpectedStation   string
 	ExpectedDay       int32
 	ExpectedVisiblity string
-	ExpectedWindSpeed float32
+	ExpectedWindSpeed Speed
 }
 
+// Shortly after the latest observation in the fixtures below
+var testReference = time.Date(2013, time.January, 31, 12, 0, 0, 0, time.UTC)
+
+var testOptions = ParseOptions{Lenient, testReference, Reported}
+
+var strictTestOptions = ParseOptions{Strict, testReference, Reported}
+
 func init() {
 }
 
@@ -55,10 +65,229 @@ func TestParseFullMetar(t *testing.T) {
 	}
 
 	checkMetarScenario(t, testMetarWithClear)
+
+	testMetarWithWeather := MetarTestScenario{
+		"KORD 210151Z 32012G20KT 2SM -SN BR BKN008 OVC015 M02/M03 A2992 RMK AO2",
+		"KORD",
+		21,
+		"2 miles",
+		12,
+	}
+
+	checkMetarScenario(t, testMetarWithWeather)
+
+	testMetarWithoutRemarks := MetarTestScenario{
+		"KMDW 211253Z 27010KT 10SM FEW250 08/02 A3001",
+		"KMDW",
+		21,
+		"10 miles",
+		10,
+	}
+
+	checkMetarScenario(t, testMetarWithoutRemarks)
+}
+
+func TestParseMetarSkipsUnrecognizedTokens(t *testing.T) {
+	const testMetar = "KORD 210051Z 15007KT 10SM XYZZY OVC060 05/01 A3010"
+	metar, err := ParseMetar(testMetar, testOptions)
+	t.Logf("Received %+v, %v", metar, err)
+	if err != nil {
+		t.Error("Failed to parse but should've succeeded")
+	}
+	if len(metar.Clouds) != 1 {
+		t.Error("Received wrong count of clouds")
+	}
+	if *metar.Temperature != 5 || metar.Pressure.InHg != 30.10 {
+		t.Error("Groups after the unrecognized token not decoded")
+	}
+	if len(metar.Unparsed) != 1 || metar.Unparsed[0] != (ParseError{"", "XYZZY", 26, "unrecognized group"}) {
+		t.Errorf("Wrong unparsed tokens %+v", metar.Unparsed)
+	}
+}
+
+func TestParseMetarPresentWeather(t *testing.T) {
+	const testMetar = "KORD 210151Z 32012KT 2SM -TSRA BR +FZRA OVC015 M02/M03 A2992"
+	metar, err := ParseMetar(testMetar, testOptions)
+	t.Logf("Received %+v, %v", metar, err)
+	if err != nil {
+		t.Error("Failed to parse but should've succeeded")
+	}
+	if len(metar.Phenomena) != 3 {
+		t.Fatal("Received wrong count of weather groups")
+	}
+	if metar.Phenomena[2].Intensity != "+" || metar.Phenomena[2].Descriptor != "FZ" {
+		t.Error("Received wrong weather group")
+	}
+	if metar.Phenomena.String() != "thunderstorm with light rain, mist, heavy freezing rain" {
+		t.Errorf("Received wrong weather description %v", metar.Phenomena)
+	}
+}
+
+func TestParseMetarReportType(t *testing.T) {
+	type reportTestCase struct {
+		RawValue          string
+		ExpectedType      string
+		ExpectedAuto      bool
+		ExpectedCorrected bool
+		ExpectedNil       bool
+	}
+	testCases := []reportTestCase{
+		{"METAR KORD 210051Z 15007KT 10SM OVC060 05/01 A3010=", "METAR", false, false, false},
+		{"SPECI KORD 210112Z AUTO 15007KT 2SM BR OVC006 05/04 A3010", "SPECI", true, false, false},
+		{"KORD 210051Z COR 15007KT 10SM OVC060 05/01 A3010", "", false, true, false},
+		{"METAR COR LFPG 210100Z 24008KT 9999 FEW030 09/05 Q1015 NOSIG=", "METAR", false, true, false},
+		{"KXYZ 211200Z NIL", "", false, false, true},
+		{"METAR KXYZ 211200Z NIL=", "METAR", false, false, true},
+	}
+	for _, testCase := range testCases {
+		metar, err := ParseMetar(testCase.RawValue, testOptions)
+		if err != nil {
+			t.Errorf("Failed to parse %v: %v", testCase.RawValue, err)
+			continue
+		}
+		if metar.Type != testCase.ExpectedType || metar.Auto != testCase.ExpectedAuto ||
+			metar.Corrected != testCase.ExpectedCorrected || metar.Nil != testCase.ExpectedNil {
+			t.Errorf("Wrong report type for %v: %+v", testCase.RawValue, metar)
+		}
+	}
+}
+
+func TestParseMetarTerminator(t *testing.T) {
+	metar, err := ParseMetar("KORD 210051Z 15007KT 10SM OVC060 05/01 A3010 RMK AO2=", testOptions)
+	if err != nil {
+		t.Error("Failed to parse but should've succeeded")
+	}
+	if len(metar.Remarks) != 1 || metar.Remarks[0] != "ASOS station" {
+		t.Errorf("Terminator not stripped from remarks %v", metar.Remarks)
+	}
+}
+
+func TestNilDetails(t *testing.T) {
+	metar, _ := ParseMetar("KXYZ 211200Z NIL", testOptions)
+	details := GetDetailMetar(metar)
+	t.Logf("Details: %v", details)
+	if !strings.Contains(details, "Report        : METAR, missing\n") || strings.Contains(details, "Wind") {
+		t.Error("Wrong details for missing report")
+	}
+}
+
+func TestParseMetarMissingGroups(t *testing.T) {
+	const testMetar = "KORD 210051Z 15007KT A3010"
+	metar, err := ParseMetar(testMetar, testOptions)
+	t.Logf("Received %+v, %v", metar, err)
+	if err != nil {
+		t.Error("Failed to parse but should've succeeded")
+	}
+	if *metar.WindSpeed != 7 || metar.Pressure.InHg != 30.10 {
+		t.Error("Groups present in the report not decoded")
+	}
+	if metar.Visibility != nil || len(metar.Clouds) != 0 || metar.Temperature != nil || metar.Dewpoint != nil {
+		t.Error("Missing groups should be left empty")
+	}
+}
+
+func TestNotReportedDetails(t *testing.T) {
+	metar, _ := ParseMetar("KORD 210051Z VRB03KT", testOptions)
+	details := GetDetailMetar(metar)
+	t.Logf("Details: %v", details)
+	for _, line := range []string{
+		"Wind direction: variable\n",
+		"Wind speed    : 3 KT\n",
+		"Wind gust     : none\n",
+		"Visibility    : not reported\n",
+		"Temperature   : not reported\n",
+		"Pressure      : not reported\n",
+	} {
+		if !strings.Contains(details, line) {
+			t.Errorf("Details missing %q", line)
+		}
+	}
+}
+
+func TestParseMetarMissingStation(t *testing.T) {
+	_, err := ParseMetar("15007KT 10SM OVC060", testOptions)
+	parseErr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("Expected a ParseError, got %v", err)
+	}
+	if parseErr.Group != "station" {
+		t.Errorf("Wrong group %v", parseErr.Group)
+	}
+}
+
+func TestParseMetarMalformedGroup(t *testing.T) {
+	type errorTestCase struct {
+		RawValue       string
+		ExpectedGroup  string
+		ExpectedToken  string
+		ExpectedOffset int
+	}
+	testCases := []errorTestCase{
+		{"KORD 210051Z 1507KT 10SM OVC060 05/01 A3010", "wind", "1507KT", 13},
+		{"KORD 210051Z 15007KT 10/SM OVC060 05/01 A3010", "visibility", "10/SM", 21},
+		{"KORD 210051Z 15007KT 10SM OVC60 05/01 A3010", "clouds", "OVC60", 26},
+		{"KORD 210051Z 15007KT 10SM -XXRA OVC060 05/01 A3010", "weather", "-XXRA", 26},
+		{"KORD 210051Z 15007KT 10SM OVC060 5/01 A3010", "temp/dew", "5/01", 33},
+		{"KORD 210051Z 15007KT 10SM OVC060 05/01 A301", "altimeter", "A301", 39},
+		{"KORD 210051Z 15007KT 10SM OVC060 05/01 A3010 RMK", "remarks", "RMK", 45},
+	}
+	for _, testCase := range testCases {
+		_, err := ParseMetar(testCase.RawValue, strictTestOptions)
+		parseErr, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("Expected a ParseError for %v, got %v", testCase.RawValue, err)
+			continue
+		}
+		t.Logf("Received %v", parseErr)
+		if parseErr.Group != testCase.ExpectedGroup || parseErr.Token != testCase.ExpectedToken ||
+			parseErr.Offset != testCase.ExpectedOffset {
+			t.Errorf("Wrong error for %v: %+v", testCase.RawValue, parseErr)
+		}
+
+		metar, err := ParseMetar(testCase.RawValue, testOptions)
+		if err != nil {
+			t.Errorf("Lenient parse of %v failed: %v", testCase.RawValue, err)
+			continue
+		}
+		if len(metar.Unparsed) != 1 || metar.Unparsed[0] != *parseErr {
+			t.Errorf("Wrong unparsed tokens for %v: %+v", testCase.RawValue, metar.Unparsed)
+		}
+	}
+}
+
+func TestParseMetarStrictRejectsUnrecognizedTokens(t *testing.T) {
+	type strictTestCase struct {
+		RawValue       string
+		ExpectedToken  string
+		ExpectedOffset int
+	}
+	testCases := []strictTestCase{
+		{"KORD 210051Z 15007KT 10SM XYZZY OVC060 05/01 A3010", "XYZZY", 26},
+		// out of order
+		{"KORD 210051Z 15007KT OVC060 10SM 05/01 A3010", "10SM", 28},
+		{"EGLL 210050Z 24010KT 9999 SCT030 08/04 Q1012 TEMPO 4000 XYZZY", "XYZZY", 56},
+	}
+	for _, testCase := range testCases {
+		_, err := ParseMetar(testCase.RawValue, strictTestOptions)
+		parseErr, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("Expected a ParseError for %v, got %v", testCase.RawValue, err)
+			continue
+		}
+		if parseErr.Group != "" || parseErr.Token != testCase.ExpectedToken ||
+			parseErr.Offset != testCase.ExpectedOffset {
+			t.Errorf("Wrong error for %v: %+v", testCase.RawValue, parseErr)
+		}
+	}
+
+	_, err := ParseMetar("KXYZ 210055Z AUTO /////KT ////SM // ///// M A//// RMK AO2", strictTestOptions)
+	if err != nil {
+		t.Errorf("Placeholders should pass a strict parse: %v", err)
+	}
 }
 
 func checkMetarScenario(t *testing.T, testMetar MetarTestScenario) {
-	metar, _ := ParseMetar(testMetar.RawValue)
+	metar, _ := ParseMetar(testMetar.RawValue, testOptions)
 
 	t.Logf("Evaluating %+v ", metar)
 	if metar.Station != testMetar.ExpectedStation {
@@ -67,10 +296,10 @@ func checkMetarScenario(t *testing.T, testMetar MetarTestScenario) {
 	if metar.Day != testMetar.ExpectedDay {
 		t.Error("Day not correct")
 	}
-	if metar.Visibility != testMetar.ExpectedVisiblity {
+	if metar.Visibility.String() != testMetar.ExpectedVisiblity {
 		t.Error("Visiblity not correct")
 	}
-	if metar.WindSpeed != testMetar.ExpectedWindSpeed {
+	if *metar.WindSpeed != testMetar.ExpectedWindSpeed {
 		t.Error("Wind speed not correct")
 	}
 	details := GetDetailMetar(metar)
@@ -80,11 +309,42 @@ func checkMetarScenario(t *testing.T, testMetar MetarTestScenario) {
 	}
 }
 
+func TestParseHourlyTempDew(t *testing.T) {
+	type tempDewTestCase struct {
+		RawValue         string
+		ExpectedTemp     Temperature
+		ExpectedDewpoint Temperature
+		ExpectedRemark   string
+	}
+	testCases := []tempDewTestCase{
+		{"KORD 210051Z 15007KT 10SM OVC060 05/01 A3010 RMK AO2 RAE02 SLP200 P0000 T00500011", 5, 1.1,
+			"Hourly temp:   5.0 °C, dew point:   1.1 °C"},
+		{"KPWK 300251Z 16009KT 10SM CLR 00/M07 A3043 RMK AO2 SLP312 T00001067 58019", 0, -6.7,
+			"Hourly temp:   0.0 °C, dew point:  -6.7 °C"},
+		// a T group without a dew point leaves the one in the body
+		{"KPWK 300251Z 16009KT 10SM CLR M00/M07 A3043 RMK AO2 T1004", -0.4, -7, "Hourly temp:  -0.4 °C"},
+		{"KPWK 300251Z 16009KT 10SM CLR 00/M07 A3043 RMK AO2", 0, -7, ""},
+	}
+	for _, testCase := range testCases {
+		metar, err := ParseMetar(testCase.RawValue, testOptions)
+		if err != nil {
+			t.Errorf("Failed to parse %v: %v", testCase.RawValue, err)
+			continue
+		}
+		if *metar.Temperature != testCase.ExpectedTemp || *metar.Dewpoint != testCase.ExpectedDewpoint {
+			t.Errorf("Wrong temperatures for %v: %v/%v", testCase.RawValue, *metar.Temperature, *metar.Dewpoint)
+		}
+		if testCase.ExpectedRemark != "" && !strings.Contains(strings.Join(metar.Remarks, "\n"), testCase.ExpectedRemark) {
+			t.Errorf("Missing remark for %v: %q", testCase.RawValue, metar.Remarks)
+		}
+	}
+}
+
 func TestParseDayTime(t *testing.T) {
 	const testDateTime = "210051Z"
-	day, time := parseDayTime(testDateTime)
-	t.Logf("Received %s, %v", day, time)
-	if day != 21 {
+	day, time, err := parseDayTime(testDateTime)
+	t.Logf("Received %v, %v, %v", day, time, err)
+	if err != nil || day != 21 {
 		t.Error("day not correct")
 	}
 	if time.Hour() != 00 {
@@ -94,51 +354,156 @@ func TestParseDayTime(t *testing.T) {
 		t.Error("Time minute not correct")
 	}
 	t.Log("OK")
+
+	for _, invalid := range []string{"320051Z", "002359Z", "212400Z", "210060Z"} {
+		if _, _, err := parseDayTime(invalid); err == nil {
+			t.Errorf("%v should have failed", invalid)
+		}
+	}
+}
+
+func TestParseMetarTime(t *testing.T) {
+	metar, _ := ParseMetar("KORD 210051Z 15007KT 10SM OVC060 05/01 A3010", testOptions)
+	expected := time.Date(2013, time.January, 21, 0, 51, 0, 0, time.UTC)
+	if !metar.Time.Equal(expected) {
+		t.Errorf("Wrong observation time %v", metar.Time)
+	}
+
+	// without a reference, the time is resolved against now
+	metar, err := ParseMetar("KORD 210051Z 15007KT 10SM OVC060 05/01 A3010", ParseOptions{})
+	if err != nil || metar.Time.After(time.Now().Add(REFERENCE_SLACK)) ||
+		metar.Time.Before(time.Now().AddDate(-1, 0, 0)) {
+		t.Errorf("Wrong observation time without a reference %v, %v", metar.Time, err)
+	}
+
+	// days and times out of range are errors for the time group in either mode
+	for _, options := range []ParseOptions{testOptions, strictTestOptions} {
+		for _, testMetar := range []string{"KORD 320051Z 15007KT 10SM OVC060 05/01 A3010",
+			"KORD 212460Z 15007KT 10SM OVC060 05/01 A3010"} {
+			_, err := ParseMetar(testMetar, options)
+			if parseErr, ok := err.(*ParseError); !ok || parseErr.Group != "time" {
+				t.Errorf("Expected a time error for %v, got %v", testMetar, err)
+			}
+		}
+	}
+}
+
+func TestResolveDayTime(t *testing.T) {
+	type resolveTestCase struct {
+		Day       int32
+		Clock     string
+		Reference time.Time
+		Expected  time.Time
+	}
+	testCases := []resolveTestCase{
+		// same day
+		{21, "0051", time.Date(2013, 1, 21, 0, 55, 0, 0, time.UTC), time.Date(2013, 1, 21, 0, 51, 0, 0, time.UTC)},
+		// end of the previous month
+		{31, "2351", time.Date(2013, 2, 1, 0, 10, 0, 0, time.UTC), time.Date(2013, 1, 31, 23, 51, 0, 0, time.UTC)},
+		// end of the previous year
+		{31, "2351", time.Date(2013, 1, 1, 0, 10, 0, 0, time.UTC), time.Date(2012, 12, 31, 23, 51, 0, 0, time.UTC)},
+		// skips a month without the day
+		{30, "1200", time.Date(2013, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2013, 1, 30, 12, 0, 0, 0, time.UTC)},
+		// reference clock slightly behind, just before a new month
+		{1, "0005", time.Date(2013, 1, 31, 23, 58, 0, 0, time.UTC), time.Date(2013, 2, 1, 0, 5, 0, 0, time.UTC)},
+		// no month has the day
+		{32, "0051", time.Date(2013, 3, 3, 1, 0, 0, 0, time.UTC), time.Time{}},
+	}
+	for _, testCase := range testCases {
+		clock, _ := time.Parse("1504", testCase.Clock)
+		resolved, ok := resolveDayTime(testCase.Day, clock, testCase.Reference)
+		if ok != !testCase.Expected.IsZero() || !resolved.Equal(testCase.Expected) {
+			t.Errorf("Wrong time for day %v %v from %v: %v", testCase.Day, testCase.Clock, testCase.Reference, resolved)
+		}
+	}
 }
 
 func TestParseWind(t *testing.T) {
 	const testWind = "18055KT"
-	direction, wind, degrees, gust := parseWind(testWind)
-	t.Logf("Received %v, %v, %v, %v", direction, wind, degrees, gust)
-	if direction != "S" {
+	wind := parseWind(testWind)
+	t.Logf("Received %+v", wind)
+	if wind.WindDirection != "S" {
 		t.Error("Direction not correct")
 	}
-	if degrees != 180 {
+	if *wind.WindDirectionDegree != 180 {
 		t.Error("Degrees not correct")
 	}
-	if wind != 55 {
+	if *wind.WindSpeed != 55 {
 		t.Error("Wind not correct")
 	}
-	if gust != 55 {
-		t.Error("Gust not correct")
+	if wind.WindGust != nil {
+		t.Error("Gust should not be reported")
 	}
 	t.Log("OK")
 }
 
 func TestParseWindWithGust(t *testing.T) {
 	const testWindWithGust = "34014G21KT"
-	direction, wind, degrees, gust := parseWind(testWindWithGust)
-	t.Logf("Received %v, %v, %v, %v", direction, wind, degrees, gust)
-	if direction != "NNW" {
+	wind := parseWind(testWindWithGust)
+	t.Logf("Received %+v", wind)
+	if wind.WindDirection != "NNW" {
 		t.Error("Direction not correct")
 	}
-	if degrees != 340 {
+	if *wind.WindDirectionDegree != 340 {
 		t.Error("Degrees not correct")
 	}
-	if wind != 14 {
+	if *wind.WindSpeed != 14 {
 		t.Error("Wind not correct")
 	}
-	if gust != 21 {
+	if *wind.WindGust != 21 {
 		t.Error("Gust not correct")
 	}
 	t.Log("OK")
 }
 
+func TestParseWindVariants(t *testing.T) {
+	type windTestCase struct {
+		RawValue string
+		Expected Wind
+	}
+	testCases := []windTestCase{
+		{"VRB05KT", Wind{WindDirection: "variable", WindUnit: "KT", WindSpeed: optional[Speed](5), WindVariable: true}},
+		{"00000KT", Wind{WindDirection: "calm", WindUnit: "KT", WindSpeed: optional[Speed](0), WindDirectionDegree: optional[float32](0),
+			WindCalm: true}},
+		{"250105G130KT", Wind{WindDirection: "WSW", WindUnit: "KT", WindSpeed: optional[Speed](105), WindGust: optional[Speed](130),
+			WindDirectionDegree: optional[float32](250)}},
+		{"270P99KT", Wind{WindDirection: "W", WindUnit: "KT", WindSpeed: optional[Speed](99), WindDirectionDegree: optional[float32](270),
+			WindSpeedAbove: true}},
+		{"09008MPS", Wind{WindDirection: "E", WindUnit: "MPS", WindSpeed: optional(speedIn(8, "MPS")), WindDirectionDegree: optional[float32](90)}},
+		{"36020G35KMH", Wind{WindDirection: "N", WindUnit: "KMH", WindSpeed: optional(speedIn(20, "KMH")), WindGust: optional(speedIn(35, "KMH")),
+			WindDirectionDegree: optional[float32](360)}},
+	}
+	for _, testCase := range testCases {
+		wind := parseWind(testCase.RawValue)
+		if !reflect.DeepEqual(wind, testCase.Expected) {
+			t.Errorf("Wrong wind for %v: %+v", testCase.RawValue, wind)
+		}
+	}
+}
+
+func TestParseMetarWindVariation(t *testing.T) {
+	const testMetar = "KORD 210051Z 21012KT 180V240 10SM OVC060 05/01 A3010"
+	metar, err := ParseMetar(testMetar, testOptions)
+	t.Logf("Received %+v, %v", metar, err)
+	if err != nil {
+		t.Error("Failed to parse but should've succeeded")
+	}
+	if *metar.WindVariableFrom != 180 || *metar.WindVariableTo != 240 {
+		t.Error("Variable range not correct")
+	}
+	if metar.VariableRange() != "180 (S) to 240 (WSW)" {
+		t.Errorf("Variable range description not correct: %v", metar.VariableRange())
+	}
+	if *metar.WindSpeed != 12 {
+		t.Error("Wind not correct")
+	}
+}
+
 func TestParseVisibilityFraction(t *testing.T) {
 	const testVisibility = "1/2SM"
 	distance := parseVisibility(testVisibility)
 	t.Logf("Received %v ", distance)
-	if distance != "1/2 miles" {
+	if distance.String() != "1/2 miles" {
 		t.Error("Visiblity not correct")
 	}
 	t.Log("OK")
@@ -156,33 +521,137 @@ func TestParseCloudsMultiple(t *testing.T) {
 
 func TestParseCloudItem(t *testing.T) {
 	const testCloud = "FEW200"
-	cloud := parseCloudDescription(testCloud)
+	cloud := parseCloudLayer(testCloud)
 	t.Logf("Received %v ", cloud)
-	if cloud != "FEW at 20000" {
+	if !reflect.DeepEqual(cloud, CloudLayer{"FEW", optional[Height](20000), "", false}) {
 		t.Error("Received wrong cloud value")
 	}
 	t.Log("OK")
 }
 
+func TestParseCloudLayers(t *testing.T) {
+	type cloudTestCase struct {
+		RawValue string
+		Expected CloudLayer
+	}
+	testCases := []cloudTestCase{
+		{"BKN035CB", CloudLayer{"BKN", optional[Height](3500), "CB", false}},
+		{"SCT020TCU", CloudLayer{"SCT", optional[Height](2000), "TCU", false}},
+		{"VV003", CloudLayer{"VV", optional[Height](300), "", true}},
+		{"OVC000", CloudLayer{"OVC", optional[Height](0), "", false}},
+		{"///015", CloudLayer{"///", optional[Height](1500), "", false}},
+		{"SKC", CloudLayer{"SKC", nil, "", false}},
+		{"CLR", CloudLayer{"CLR", nil, "", false}},
+		{"NSC", CloudLayer{"NSC", nil, "", false}},
+		{"NCD", CloudLayer{"NCD", nil, "", false}},
+	}
+	for _, testCase := range testCases {
+		cloud := parseCloudLayer(testCase.RawValue)
+		if !reflect.DeepEqual(cloud, testCase.Expected) {
+			t.Errorf("Wrong cloud layer for %v: %+v", testCase.RawValue, cloud)
+		}
+	}
+	if !parseCloudLayer("NCD").Clear() || parseCloudLayer("OVC000").Clear() {
+		t.Error("Wrong clear flag")
+	}
+}
+
+func TestCloudDetails(t *testing.T) {
+	metar, _ := ParseMetar("KORD 210051Z 15007KT 1/4SM FG VV002 BKN010CB OVC020 05/05 A3010", testOptions)
+	details := GetDetailMetar(metar)
+	t.Logf("Details: %v", details)
+	if !strings.Contains(details, "Clouds        : vertical visibility 200 ft, BKN at 1000 ft CB, OVC at 2000 ft\n") {
+		t.Error("Wrong cloud details")
+	}
+}
+
 func TestParseTempDew(t *testing.T) {
 	const testTemp = "05/M01"
 	temperature, dewPoint := parseTempDew(testTemp)
-	t.Logf("Received %v, %v", temperature, dewPoint)
-	if temperature != 5.0 {
+	t.Logf("Received %v, %v", *temperature, *dewPoint)
+	if *temperature != 5.0 {
 		t.Error("Received wrong temperature")
 	}
 
-	if dewPoint != -1.0 {
+	if *dewPoint != -1.0 {
 		t.Error("Received wrong dew point")
 	}
 	t.Log("OK")
 }
 
+func TestParseTempDewMissing(t *testing.T) {
+	temperature, dewPoint := parseTempDew("M05/")
+	if *temperature != -5 || dewPoint != nil {
+		t.Error("Missing dew point should be nil")
+	}
+	temperature, dewPoint = parseTempDew("/////")
+	if temperature != nil || dewPoint != nil {
+		t.Error("Missing temperature and dew point should be nil")
+	}
+}
+
+func TestParseMetarUnavailableSensors(t *testing.T) {
+	const testMetar = "KXYZ 210055Z AUTO /////KT ////SM // //////CB BKN/// ///// M A//// RMK AO2 PWINO TSNO $"
+	metar, err := ParseMetar(testMetar, testOptions)
+	t.Logf("Received %+v, %v", metar, err)
+	if err != nil {
+		t.Fatalf("Failed to parse but should've succeeded: %v", err)
+	}
+	if metar.WindUnit != "KT" || metar.WindSpeed != nil || metar.WindDirectionDegree != nil || metar.WindCalm {
+		t.Error("Wind should be unavailable")
+	}
+	if metar.Visibility != nil || len(metar.Phenomena) != 0 {
+		t.Error("Visibility and weather should be unavailable")
+	}
+	if len(metar.Clouds) != 2 || metar.Clouds[0].Convective != "CB" || metar.Clouds[0].Base != nil ||
+		metar.Clouds[1].Coverage != "BKN" || metar.Clouds[1].Base != nil {
+		t.Errorf("Wrong clouds %+v", metar.Clouds)
+	}
+	if metar.Temperature != nil || metar.Dewpoint != nil || metar.Pressure != nil {
+		t.Error("Temperature, dew point and pressure should be unavailable")
+	}
+	if len(metar.Remarks) != 4 || metar.Remarks[3] != "Station needs maintenance" {
+		t.Errorf("Wrong remarks %v", metar.Remarks)
+	}
+}
+
+func TestParseWindPartlyUnavailable(t *testing.T) {
+	wind := parseWind("///05KT")
+	if wind.WindDirectionDegree != nil || *wind.WindSpeed != 5 {
+		t.Errorf("Wrong wind %+v", wind)
+	}
+	wind = parseWind("240//KT")
+	if *wind.WindDirectionDegree != 240 || wind.WindSpeed != nil {
+		t.Errorf("Wrong wind %+v", wind)
+	}
+}
+
 func TestParsePressure(t *testing.T) {
 	const testPressure = "A3006"
 	pressure := parsePressure(testPressure)
 	t.Logf("Received %v", pressure)
-	if pressure != 30.06 {
+	if pressure.InHg != 30.06 || pressure.Unit != "inHg" {
 		t.Error("Received wrong pressure")
 	}
 }
+
+func TestParsePressureHectopascals(t *testing.T) {
+	const testPressure = "Q1013"
+	pressure := parsePressure(testPressure)
+	t.Logf("Received %v", pressure)
+	if pressure.HPa != 1013 || pressure.Unit != "hPa" {
+		t.Error("Received wrong pressure")
+	}
+}
+
+func TestParseMetarDualPressure(t *testing.T) {
+	const testMetar = "MMMX 210046Z 36005KT 7SM SCT200 18/M02 A3012 Q1020"
+	metar, err := ParseMetar(testMetar, testOptions)
+	t.Logf("Received %+v, %v", metar, err)
+	if err != nil {
+		t.Error("Failed to parse but should've succeeded")
+	}
+	if metar.Pressure.InHg != 30.12 || metar.Pressure.HPa != 1020 || metar.Pressure.Unit != "inHg" {
+		t.Errorf("Received wrong pressure %+v", metar.Pressure)
+	}
+}
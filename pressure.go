@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+)
+
+const HPA_PER_INHG = 33.8639
+
+// Altimeter setting as reported in inches of mercury, hectopascals or both
+type Pressure struct {
+	InHg, HPa float32 // as reported, zero when the report had no group in that unit
+	Unit      string  // unit of the first group reported, "inHg" or "hPa"
+}
+
+// Returns the pressure in the given unit, inHg, hPa or Pa, preferring a
+// reported value over a converted one
+func (this Pressure) In(unit string) float32 {
+	switch {
+	case unit == "Pa":
+		return this.In("hPa") * 100
+	case unit == "hPa" && this.HPa != 0:
+		return this.HPa
+	case unit == "hPa":
+		return this.InHg * HPA_PER_INHG
+	case this.InHg != 0:
+		return this.InHg
+	}
+	return this.HPa / HPA_PER_INHG
+}
+
+// Formats the pressure in the given unit, or the reported unit if none is given
+func (this Pressure) Format(unit string) string {
+	if this.Unit == "" {
+		return ""
+	}
+	if unit == "" {
+		unit = this.Unit
+	}
+	switch unit {
+	case "hPa", "Pa":
+		return fmt.Sprintf("%.0f %s", this.In(unit), unit)
+	}
+	return fmt.Sprintf("%.2f inHg", this.In("inHg"))
+}
+
+func (this Pressure) String() string {
+	return this.Format("")
+}
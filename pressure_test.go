@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestPressureConversion(t *testing.T) {
+	pressure := Pressure{InHg: 29.92, Unit: "inHg"}
+	if pressure.Format("hPa") != "1013 hPa" {
+		t.Errorf("Wrong conversion to hPa: %v", pressure.Format("hPa"))
+	}
+	if pressure.String() != "29.92 inHg" {
+		t.Errorf("Wrong reported pressure: %v", pressure)
+	}
+
+	pressure = Pressure{HPa: 1013, Unit: "hPa"}
+	if pressure.Format("inHg") != "29.91 inHg" {
+		t.Errorf("Wrong conversion to inHg: %v", pressure.Format("inHg"))
+	}
+	if pressure.String() != "1013 hPa" {
+		t.Errorf("Wrong reported pressure: %v", pressure)
+	}
+}
+
+func TestPressurePrefersReportedValue(t *testing.T) {
+	pressure := Pressure{InHg: 29.92, HPa: 1012, Unit: "hPa"}
+	if pressure.In("hPa") != 1012 || pressure.In("inHg") != 29.92 {
+		t.Errorf("Wrong pressure %+v", pressure)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Relative humidity as a percentage
+type Humidity float32
+
+func (this Humidity) String() string {
+	return fmt.Sprintf("%.0f%%", float32(this))
+}
+
+// Saturation vapor pressure over water in hPa, by the Magnus formula
+func vaporPressure(celsius float64) float64 {
+	return 6.112 * math.Exp(17.62*celsius/(243.12+celsius))
+}
+
+// Relative humidity as a percentage, nil without both temperature and dew point
+func (this Metar) RelativeHumidity() (humidity *Humidity) {
+	temperature, dewpoint := this.Temperature, this.Dewpoint
+	if temperature == nil || dewpoint == nil {
+		return
+	}
+	percent := 100 * vaporPressure(float64(*dewpoint)) / vaporPressure(float64(*temperature))
+	return optional(Humidity(math.Min(percent, 100)))
+}
+
+// How far the temperature is above the dew point
+func (this Metar) DewpointDepression() (depression *Temperature) {
+	temperature, dewpoint := this.Temperature, this.Dewpoint
+	if temperature == nil || dewpoint == nil {
+		return
+	}
+	return optional(*temperature - *dewpoint)
+}
+
+// The temperature a wet thermometer would cool to, by Stull's approximation
+func (this Metar) WetBulb() (wetBulb *Temperature) {
+	temperature := this.Temperature
+	humidity := this.RelativeHumidity()
+	if temperature == nil || humidity == nil {
+		return
+	}
+	t, rh := float64(*temperature), float64(*humidity)
+	value := t*math.Atan(0.151977*math.Sqrt(rh+8.313659)) + math.Atan(t+rh) - math.Atan(rh-1.676331) +
+		0.00391838*math.Pow(rh, 1.5)*math.Atan(0.023101*rh) - 4.686035
+	return optional(Temperature(value))
+}
+
+// The apparent temperature in hot, humid air by the NWS formula, nil
+// below 80 °F where it doesn't apply
+func (this Metar) HeatIndex() (heatIndex *Temperature) {
+	temperature := this.Temperature
+	humidity := this.RelativeHumidity()
+	if temperature == nil || humidity == nil || temperature.In("F") < 80 {
+		return
+	}
+	t, rh := float64(temperature.In("F")), float64(*humidity)
+	value := 0.5 * (t + 61 + (t-68)*1.2 + rh*0.094)
+	if (value+t)/2 >= 80 {
+		value = -42.379 + 2.04901523*t + 10.14333127*rh - 0.22475541*t*rh - 0.00683783*t*t -
+			0.05481717*rh*rh + 0.00122874*t*t*rh + 0.00085282*t*rh*rh - 0.00000199*t*t*rh*rh
+		switch {
+		case rh < 13 && t <= 112:
+			value -= (13 - rh) / 4 * math.Sqrt((17-math.Abs(t-95))/17)
+		case rh > 85 && t <= 87:
+			value += (rh - 85) / 10 * (87 - t) / 5
+		}
+	}
+	return optional(Temperature((value - 32) * 5 / 9))
+}
+
+// The apparent temperature in cold, moving air by the North American
+// formula, nil above 10 °C or in winds of 3 mph or less
+func (this Metar) WindChill() (windChill *Temperature) {
+	temperature := this.Temperature
+	if temperature == nil || *temperature > 10 || this.WindSpeed == nil || this.WindSpeed.In("MPH") <= 3 {
+		return
+	}
+	t, v := float64(*temperature), math.Pow(float64(this.WindSpeed.In("KMH")), 0.16)
+	return optional(Temperature(13.12 + 0.6215*t - 11.37*v + 0.3965*t*v))
+}
+
+// The Canadian humidex, how hot humid air feels, nil below 20 °C where it isn't used
+func (this Metar) Humidex() (humidex *Temperature) {
+	temperature, dewpoint := this.Temperature, this.Dewpoint
+	if temperature == nil || dewpoint == nil || *temperature < 20 {
+		return
+	}
+	vapor := 6.11 * math.Exp(5417.7530*(1/273.16-1/(273.15+float64(*dewpoint))))
+	return optional(*temperature + Temperature(0.5555*(vapor-10)))
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// Reports whether a derived value is within a tenth of a degree of the expected one, nil expecting NaN
+func nearly(value *Temperature, expected float64) bool {
+	if value == nil {
+		return math.IsNaN(expected)
+	}
+	return math.Abs(float64(*value)-expected) <= 0.1
+}
+
+func TestPsychrometrics(t *testing.T) {
+	type psychrometricTestCase struct {
+		RawValue           string
+		ExpectedHumidity   Humidity
+		ExpectedDepression float64
+		ExpectedWetBulb    float64
+		ExpectedHeatIndex  float64
+		ExpectedHumidex    float64
+		ExpectedWindChill  float64
+	}
+	none := math.NaN()
+	testCases := []psychrometricTestCase{
+		// 86 °F at 55% feels like 89 °F
+		{"KORD 210051Z 18010KT 10SM CLR 30/20 A3010", 55, 10, 23.2, 31.9, 37.6, none},
+		{"KORD 210051Z 18010KT 10SM CLR 15/11 A3010", 77, 4, 12.4, none, none, none},
+		// -10 °C in a 20 km/h wind
+		{"CYYZ 210000Z 27011KT 15SM FEW030 M10/M15 A3010", 67, 5, -11.8, none, none, -17.9},
+		{"CYYZ 210000Z 00000KT 15SM FEW030 M10/M15 A3010", 67, 5, -11.8, none, none, none},
+		// the T group is more precise than the body
+		{"KORD 210051Z 15007KT 10SM OVC060 05/M01 A3010 RMK AO2 T00461012", 66, 5.8, 1.6, none, none, 1.6},
+	}
+	for _, testCase := range testCases {
+		metar, err := ParseMetar(testCase.RawValue, testOptions)
+		if err != nil {
+			t.Errorf("Failed to parse %v: %v", testCase.RawValue, err)
+			continue
+		}
+		humidity := metar.RelativeHumidity()
+		if humidity == nil || humidity.String() != testCase.ExpectedHumidity.String() {
+			t.Errorf("Wrong humidity for %v: %v", testCase.RawValue, humidity)
+		}
+		if !nearly(metar.DewpointDepression(), testCase.ExpectedDepression) ||
+			!nearly(metar.WetBulb(), testCase.ExpectedWetBulb) ||
+			!nearly(metar.HeatIndex(), testCase.ExpectedHeatIndex) ||
+			!nearly(metar.Humidex(), testCase.ExpectedHumidex) ||
+			!nearly(metar.WindChill(), testCase.ExpectedWindChill) {
+			t.Errorf("Wrong derived values for %v: %v %v %v %v %v", testCase.RawValue, metar.DewpointDepression(),
+				metar.WetBulb(), metar.HeatIndex(), metar.Humidex(), metar.WindChill())
+		}
+	}
+
+	metar, _ := ParseMetar("KORD 210051Z 18010KT 10SM CLR", testOptions)
+	if metar.RelativeHumidity() != nil || metar.WetBulb() != nil || metar.WindChill() != nil {
+		t.Error("Derived values without a temperature should be nil")
+	}
+}
+
+func TestPsychrometricDetails(t *testing.T) {
+	metar, _ := ParseMetar("KORD 210051Z 18010KT 10SM CLR 30/20 A3010", testOptions)
+	details := GetDetailMetar(metar)
+	if !strings.Contains(details, "Humidity      : 55%\nDew spread    : 10 °C\nWet bulb      : 23.2 °C\n"+
+		"Heat index    : 31.9 °C\nHumidex       : 37.6 °C\n") {
+		t.Errorf("Wrong derived details %v", details)
+	}
+	if strings.Contains(details, "Wind chill") {
+		t.Errorf("Wind chill shouldn't apply %v", details)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"github.com/mragh/metarg/compass"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// The highest wind since the last report, e.g. PK WND 28045/15
+type PeakWind struct {
+	Direction float32
+	Speed     Speed
+	Time      time.Time
+}
+
+// A change in wind direction, e.g. WSHFT 30 FROPA
+type WindShift struct {
+	Time           time.Time
+	FrontalPassage bool // the shift came with a front
+}
+
+// Prevailing visibility varying between two values, e.g. VIS 1/2V2
+type VisibilityRange struct {
+	Min, Max Visibility
+	Location string // a runway, e.g. RWY11, or "" at the station's sensor
+}
+
+// A ceiling varying between two heights, e.g. CIG 005V010
+type CeilingRange struct {
+	Min, Max Height
+}
+
+// Visibility at a location other than the station's sensor, e.g. VIS 3/4 RWY11
+type LocationVisibility struct {
+	Visibility
+	Location string
+}
+
+// A remark spanning one or more tokens, each matching its pattern in
+// turn.  A pattern that matches an empty string is optional, and its
+// value is empty when it's left out.  The decoder keeps the remark's
+// value on the metar and translates it.
+type remarkGroup struct {
+	patterns []*regexp.Regexp
+	decode   func(target *Metar, values []string, units Units) string
+}
+
+// A visibility in statute miles as given in remarks, e.g. M1/4 or 1 1/2
+const remarkDistance = `M?(\d+ \d\/\d+|\d\/\d+|\d+)`
+
+var remarkDistanceRegex = regexp.MustCompile(`^` + remarkDistance + `$`)
+var remarkDistanceRangeRegex = regexp.MustCompile(`^(` + remarkDistance + `)V(` + remarkDistance + `)$`)
+var remarkLocationRegex = regexp.MustCompile(`^RWY\d{2}[LCR]?$`)
+var remarkOptionalLocationRegex = regexp.MustCompile(`^(RWY\d{2}[LCR]?)?$`)
+var remarkTimeRegex = regexp.MustCompile(`^(\d{2}|\d{4})$`)
+var peakWindRegex = regexp.MustCompile(`^(\d{3})(\d{2,3})\/(\d{2}|\d{4})$`)
+var ceilingRangeRegex = regexp.MustCompile(`^(\d{3})V(\d{3})$`)
+
+// Remarks in the order they're tried, the first to match being decoded
+var remarkGroups = []remarkGroup{
+	{[]*regexp.Regexp{regexp.MustCompile(`^PK$`), regexp.MustCompile(`^WND$`), peakWindRegex},
+		(*Metar).decodePeakWind},
+	{[]*regexp.Regexp{regexp.MustCompile(`^WSHFT$`), remarkTimeRegex, regexp.MustCompile(`^(FROPA)?$`)},
+		(*Metar).decodeWindShift},
+	{[]*regexp.Regexp{regexp.MustCompile(`^(TWR|SFC)$`), regexp.MustCompile(`^VIS$`), remarkDistanceRegex},
+		(*Metar).decodeTowerSurfaceVisibility},
+	{[]*regexp.Regexp{regexp.MustCompile(`^VIS$`), remarkDistanceRangeRegex, remarkOptionalLocationRegex},
+		(*Metar).decodeVisibilityRange},
+	{[]*regexp.Regexp{regexp.MustCompile(`^VIS$`), remarkDistanceRegex, remarkLocationRegex},
+		(*Metar).decodeLocationVisibility},
+	{[]*regexp.Regexp{regexp.MustCompile(`^CIG$`), ceilingRangeRegex},
+		(*Metar).decodeCeilingRange},
+	{[]*regexp.Regexp{weatherEventsRegex},
+		(*Metar).decodeWeatherEvents},
+	{[]*regexp.Regexp{lightningFrequencyRegex, lightningRegex, convectionDistanceRegex, convectionDirectionRegex,
+		convectionMovementRegex}, (*Metar).decodeLightning},
+	{[]*regexp.Regexp{convectionRegex, convectionDistanceRegex, convectionDirectionRegex, convectionMovementRegex},
+		(*Metar).decodeConvection},
+}
+
+// Fractions of a mile split across tokens, and movements, joined back
+// together before matching: 1 1/2V2 1/2, 1 1/2, 1/2V1 1/2, MOV NE
+var remarkJoinedGroups = [][]*regexp.Regexp{
+	{regexp.MustCompile(`^MOV$`), regexp.MustCompile(`^` + compassPoint + `$`)},
+	{regexp.MustCompile(`^M?\d+$`), regexp.MustCompile(`^\d\/\d+V\d+$`), regexp.MustCompile(`^\d\/\d+$`)},
+	{regexp.MustCompile(`^(\S*V)?M?\d+$`), regexp.MustCompile(`^\d\/\d+(V\d+)?$`)},
+}
+
+// Finds the first remark group the tokens start with, returning the value
+// for each of its patterns and how many tokens it spans
+func matchRemarkGroup(tokens []metarToken) (group remarkGroup, values []string, count int) {
+	for _, group := range remarkGroups {
+		if values, count = matchRemarkPatterns(tokens, group.patterns); count > 0 {
+			return group, values, count
+		}
+	}
+	return
+}
+
+// Matches the tokens against each pattern in turn, passing over optional
+// patterns the next token doesn't fit.  The count is zero if they don't match.
+func matchRemarkPatterns(tokens []metarToken, patterns []*regexp.Regexp) (values []string, count int) {
+	for _, pattern := range patterns {
+		switch {
+		case count < len(tokens) && pattern.MatchString(tokens[count].value):
+			values = append(values, tokens[count].value)
+			count++
+		case pattern.MatchString(""):
+			values = append(values, "")
+		default:
+			return nil, 0
+		}
+	}
+	return
+}
+
+// Parses a distance in statute miles from the remarks, e.g. 1 1/2
+func parseRemarkDistance(distanceFlat string) Visibility {
+	return parseVisibility(strings.Join(strings.Fields(distanceFlat), " ") + "SM")
+}
+
+func (this *Metar) decodePeakWind(values []string, units Units) string {
+	matches := peakWindRegex.FindStringSubmatch(values[2])
+	this.PeakWind = &PeakWind{parseSignedFloat(matches[1]), Speed(parseSignedFloat(matches[2])),
+		resolveRemarkTime(matches[3], this.Time)}
+	return fmt.Sprintf("Peak wind %v (%s) at %s at %s", this.PeakWind.Direction,
+		compass.GetCompassAbbreviation(this.PeakWind.Direction), this.PeakWind.Speed.Format(units.Speed),
+		this.PeakWind.Time.Format("15:04"))
+}
+
+func (this *Metar) decodeWindShift(values []string, units Units) string {
+	this.WindShift = &WindShift{resolveRemarkTime(values[1], this.Time), values[2] != ""}
+	translation := "Wind shift at " + this.WindShift.Time.Format("15:04")
+	if this.WindShift.FrontalPassage {
+		translation += " with frontal passage"
+	}
+	return translation
+}
+
+func (this *Metar) decodeTowerSurfaceVisibility(values []string, units Units) string {
+	visibility := parseRemarkDistance(values[2])
+	if values[0] == "TWR" {
+		this.TowerVisibility = &visibility
+		return "Tower visibility " + visibility.Format(units.Distance)
+	}
+	this.SurfaceVisibility = &visibility
+	return "Surface visibility " + visibility.Format(units.Distance)
+}
+
+func (this *Metar) decodeVisibilityRange(values []string, units Units) string {
+	matches := remarkDistanceRangeRegex.FindStringSubmatch(values[1])
+	this.VisibilityRange = &VisibilityRange{Min: parseRemarkDistance(matches[1]), Max: parseRemarkDistance(matches[3])}
+	translation := "Visibility varying from " + this.VisibilityRange.Min.Format(units.Distance) +
+		" to " + this.VisibilityRange.Max.Format(units.Distance)
+	if values[2] != "" {
+		this.VisibilityRange.Location = values[2]
+		translation += " at " + describeRemarkLocation(values[2])
+	}
+	return translation
+}
+
+func (this *Metar) decodeLocationVisibility(values []string, units Units) string {
+	visibility := LocationVisibility{parseRemarkDistance(values[1]), values[2]}
+	this.OtherVisibilities = append(this.OtherVisibilities, visibility)
+	return "Visibility " + visibility.Format(units.Distance) + " at " + describeRemarkLocation(visibility.Location)
+}
+
+func (this *Metar) decodeCeilingRange(values []string, units Units) string {
+	matches := ceilingRangeRegex.FindStringSubmatch(values[1])
+	this.CeilingRange = &CeilingRange{Height(parseSignedFloat(matches[1]) * 100),
+		Height(parseSignedFloat(matches[2]) * 100)}
+	return "Ceiling varying from " + this.CeilingRange.Min.Format(units.Height) +
+		" to " + this.CeilingRange.Max.Format(units.Height)
+}
+
+func (this *Metar) decodeWeatherEvents(values []string, units Units) string {
+	events := parseWeatherEvents(values[0], this.Time)
+	if events == nil {
+		return ""
+	}
+	this.WeatherEvents = append(this.WeatherEvents, events...)
+	return describeWeatherEvents(events)
+}
+
+// Describes a location from the remarks, e.g. "runway 11"
+func describeRemarkLocation(location string) string {
+	return "runway " + strings.TrimPrefix(location, "RWY")
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeRemarkGroups(t *testing.T) {
+	type remarkGroupTestCase struct {
+		RemarksValue     string
+		ExpectedRemarks  string
+		ExpectedUnparsed int // remarks left untranslated
+	}
+	testCases := []remarkGroupTestCase{
+		{"AO2 PK WND 28045/15 SLP200", "ASOS station|Peak wind 280 (W) at 45 KT at 00:15|Sea level pressure 1020.0 mb", 0},
+		{"PK WND 050105/2358", "Peak wind 50 (NE) at 105 KT at 23:58", 0},
+		{"WSHFT 30 FROPA", "Wind shift at 00:30 with frontal passage", 0},
+		{"WSHFT 0012", "Wind shift at 00:12", 0},
+		{"VIS 1/2V2", "Visibility varying from 1/2 miles to 2 miles", 0},
+		{"VIS 1 1/2V2 1/2 RWY11", "Visibility varying from 1 1/2 miles to 2 1/2 miles at runway 11", 0},
+		{"VIS M1/4V1", "Visibility varying from less than 1/4 miles to 1 miles", 0},
+		{"CIG 005V010", "Ceiling varying from 500 ft to 1000 ft", 0},
+		{"TWR VIS 1 1/2", "Tower visibility 1 1/2 miles", 0},
+		{"SFC VIS 2", "Surface visibility 2 miles", 0},
+		{"VIS 3/4 RWY11", "Visibility 3/4 miles at runway 11", 0},
+		// incomplete groups fall back to single-token remarks
+		{"PK WND", "|", 2},
+		{"VIS 2", "", 1},
+	}
+	observed := time.Date(2013, time.January, 21, 0, 51, 0, 0, time.UTC)
+	for _, testCase := range testCases {
+		metar := Metar{Time: observed}
+		metar.decodeRemarks(testCase.RemarksValue, Units{})
+		if strings.Join(metar.Remarks, "|") != testCase.ExpectedRemarks {
+			t.Errorf("Wrong remarks for %v: %q", testCase.RemarksValue, metar.Remarks)
+		}
+		unparsed := 0
+		for _, remark := range metar.Remarks {
+			if remark == "" {
+				unparsed++
+			}
+		}
+		if unparsed != testCase.ExpectedUnparsed {
+			t.Errorf("Wrong count of untranslated remarks for %v: %v", testCase.RemarksValue, unparsed)
+		}
+	}
+}
+
+func TestParseMetarRemarkGroups(t *testing.T) {
+	metar, err := ParseMetar("KORD 210051Z 30025G45KT 1SM +TSRA BKN008 OVC015 15/14 A2992 "+
+		"RMK AO2 PK WND 28045/15 WSHFT 30 FROPA TWR VIS 1 1/2 SFC VIS 1 VIS 3/4V2 CIG 006V012 VIS 1/2 RWY11 "+
+		"TSB2359 T01500144", testOptions)
+	if err != nil {
+		t.Fatalf("Failed to parse but should've succeeded: %v", err)
+	}
+	if metar.PeakWind == nil || metar.PeakWind.Direction != 280 || metar.PeakWind.Speed != 45 ||
+		!metar.PeakWind.Time.Equal(time.Date(2013, time.January, 21, 0, 15, 0, 0, time.UTC)) {
+		t.Errorf("Wrong peak wind %+v", metar.PeakWind)
+	}
+	if metar.WindShift == nil || !metar.WindShift.FrontalPassage || metar.WindShift.Time.Minute() != 30 {
+		t.Errorf("Wrong wind shift %+v", metar.WindShift)
+	}
+	if metar.TowerVisibility == nil || metar.TowerVisibility.String() != "1 1/2 miles" ||
+		metar.SurfaceVisibility == nil || metar.SurfaceVisibility.String() != "1 miles" {
+		t.Errorf("Wrong tower and surface visibility %+v %+v", metar.TowerVisibility, metar.SurfaceVisibility)
+	}
+	if metar.VisibilityRange == nil || metar.VisibilityRange.Min.String() != "3/4 miles" ||
+		metar.VisibilityRange.Max.String() != "2 miles" || metar.VisibilityRange.Location != "" {
+		t.Errorf("Wrong visibility range %+v", metar.VisibilityRange)
+	}
+	if metar.CeilingRange == nil || metar.CeilingRange.Min != 600 || metar.CeilingRange.Max != 1200 {
+		t.Errorf("Wrong ceiling range %+v", metar.CeilingRange)
+	}
+	if len(metar.OtherVisibilities) != 1 || metar.OtherVisibilities[0].Location != "RWY11" ||
+		metar.OtherVisibilities[0].String() != "1/2 miles" {
+		t.Errorf("Wrong other visibilities %+v", metar.OtherVisibilities)
+	}
+	if len(metar.WeatherEvents) != 1 || len(metar.Remarks) != 10 {
+		t.Errorf("Wrong remarks %q", metar.Remarks)
+	}
+
+	metar, _ = ParseMetar("KORD 210051Z 30025G45KT 1SM BKN008 15/14 A2992 RMK PK WND 28045/15 CIG 006V012",
+		ParseOptions{Lenient, testReference, Metric})
+	details := GetDetailMetar(metar)
+	if !strings.Contains(details, "Peak wind 280 (W) at 83.3 KMH at 00:15\nCeiling varying from 182.9 m to 365.8 m\n") {
+		t.Errorf("Wrong metric remarks %v", details)
+	}
+}
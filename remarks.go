@@ -7,21 +7,24 @@ import (
 	"strings"
 )
 
-func parseRemark(remark string) (translation string) {
+// Translates a remark, giving quantities in the given units
+func parseRemark(remark string, units Units) (translation string) {
 
 	remarkMap := map[string]func(flatValue string) string{
 		`^AO[1,2]$`:        parseStationType,
-		`^SLP\d\d\d$`:      parseSeaLevelPressure,
+		`^SLP\d\d\d$`:      func(flatValue string) string { return parseSeaLevelPressure(flatValue, units) },
 		`^WEA\:something$`: parseWeatherAddl,
 		`^PRES[FR]R$`:      parsePressureChange,
-		`^1\d{4}$`:         parseMax6HrTemp,
-		`^2\d{4}$`:         parseMin6HrTemp,
-		`^4\/\d{3}$`:       parseSnowCoverage,
-		`^5[01]\d{3}$`:     parsePressureTendency,
-		`^6\d{4}$`:         parse6HourPrecipitation,
-		`^7\d{4}$`:         parse24HourPrecipitation,
+		`^1\d{4}$`:         func(flatValue string) string { return parseMax6HrTemp(flatValue, units) },
+		`^2\d{4}$`:         func(flatValue string) string { return parseMin6HrTemp(flatValue, units) },
+		`^4\/\d{3}$`:       func(flatValue string) string { return parseSnowCoverage(flatValue, units) },
+		`^5[01]\d{3}$`:     func(flatValue string) string { return parsePressureTendency(flatValue, units) },
+		`^6\d{4}$`:         func(flatValue string) string { return parse6HourPrecipitation(flatValue, units) },
+		`^7\d{4}$`:         func(flatValue string) string { return parse24HourPrecipitation(flatValue, units) },
 		`^8/[lmh]$`:        parseCloudType,
-		`^933\d{3}$`:       parseSnowWaterEq,
+		`^933\d{3}$`:       func(flatValue string) string { return parseSnowWaterEq(flatValue, units) },
+		`^T(\d{4}){1,2}$`:  func(flatValue string) string { return parseHourlyTempDew(flatValue, units) },
+		`^(\$|[A-Z]+NO)$`:  parseSensorStatus,
 	}
 	for rgx, evaluator := range remarkMap {
 		expression := regexp.MustCompile(rgx)
@@ -41,10 +44,19 @@ func parseStationType(remark string) (translation string) {
 	return ""
 }
 
-func parseSeaLevelPressure(remark string) (translation string) {
+func parseSeaLevelPressure(remark string, units Units) (translation string) {
 	pressure, _ := strconv.ParseFloat(remark[3:], 64)
 	pressure = pressure / 10
-	return fmt.Sprintf("Sea level pressure %v mb", pressure)
+	// only tenths and units are coded, e.g. SLP134 is 1013.4 hPa and SLP982 is 998.2 hPa
+	if pressure < 50 {
+		pressure += 1000
+	} else {
+		pressure += 900
+	}
+	if units.Pressure != "" {
+		return "Sea level pressure " + formatRemarkPressure(pressure, units.Pressure)
+	}
+	return fmt.Sprintf("Sea level pressure %.1f mb", pressure)
 }
 
 func parseWeatherAddl(remark string) (translation string) {
@@ -61,21 +73,21 @@ func parsePressureChange(remark string) (translation string) {
 	return ""
 }
 
-func parseMax6HrTemp(remark string) (translation string) {
+func parseMax6HrTemp(remark string, units Units) (translation string) {
 	var floatValue float64
 	expression := regexp.MustCompile(`1(\d{4})`)
 	matches := expression.FindStringSubmatch(remark)
 	floatValue = parseRemarkSignedValue(matches[1])
-	translation = fmt.Sprintf("Max temp in 6 hrs:  %4.1f °C", floatValue)
+	translation = "Max temp in 6 hrs:  " + formatRemarkTemperature(floatValue, units.Temperature)
 	return
 }
 
-func parseMin6HrTemp(remark string) (translation string) {
+func parseMin6HrTemp(remark string, units Units) (translation string) {
 	var floatValue float64
 	expression := regexp.MustCompile(`2(\d{4})`)
 	matches := expression.FindStringSubmatch(remark)
 	floatValue = parseRemarkSignedValue(matches[1])
-	translation = fmt.Sprintf("Min temp in 6 hrs:  %4.1f °C", floatValue)
+	translation = "Min temp in 6 hrs:  " + formatRemarkTemperature(floatValue, units.Temperature)
 	return
 }
 
@@ -89,26 +101,26 @@ func parseRemarkSignedValue(value string) (floatValue float64) {
 	return
 }
 
-func parseSnowCoverage(remark string) (translation string) {
+func parseSnowCoverage(remark string, units Units) (translation string) {
 	var floatValue float64
 	expression := regexp.MustCompile(`4\/(\d{3})`)
 	matches := expression.FindStringSubmatch(remark)
 	floatValue, _ = strconv.ParseFloat(matches[1], 32)
-	translation = fmt.Sprintf("Snow coverage:  %4.1f\"", floatValue)
+	translation = "Snow coverage:  " + formatRemarkDepth("%4.1f", floatValue, units.Depth)
 	return
 }
 
-func parse6HourPrecipitation(remark string) (translation string) {
+func parse6HourPrecipitation(remark string, units Units) (translation string) {
 	var floatValue float64
 	floatValue, _ = strconv.ParseFloat(remark[1:], 32)
-	translation = fmt.Sprintf("6-hour precipitation: %4.1f\"", floatValue/100)
+	translation = "6-hour precipitation: " + formatRemarkDepth("%4.1f", floatValue/100, units.Depth)
 	return
 }
 
-func parse24HourPrecipitation(remark string) (translation string) {
+func parse24HourPrecipitation(remark string, units Units) (translation string) {
 	var floatValue float64
 	floatValue, _ = strconv.ParseFloat(remark[1:], 32)
-	translation = fmt.Sprintf("24-hour precipitation: %4.1f\"", floatValue/100)
+	translation = "24-hour precipitation: " + formatRemarkDepth("%4.1f", floatValue/100, units.Depth)
 	return
 }
 
@@ -131,21 +143,79 @@ func parseCloudType(remark string) (translation string) {
 	return
 }
 
-func parsePressureTendency(remark string) (translation string) {
+func parsePressureTendency(remark string, units Units) (translation string) {
 	pressure := parseOneSignedFloat(remark[1:]) * .1
 
+	if units.Pressure != "" {
+		return "Pressure tendency:  " + formatRemarkPressure(pressure, units.Pressure)
+	}
 	translation = fmt.Sprintf("Pressure tendency:  %4.1f mb", pressure)
 
 	return
 }
 
-func parseSnowWaterEq(remark string) (translation string) {
+func parseSnowWaterEq(remark string, units Units) (translation string) {
 	var floatValue float64
 	floatValue, _ = strconv.ParseFloat(remark[3:], 32)
-	translation = fmt.Sprintf("New snow coverage (water eq.): %3.0f\"", floatValue)
+	translation = "New snow coverage (water eq.): " + formatRemarkDepth("%3.0f", floatValue, units.Depth)
 	return
 }
 
+var hourlyTempDewRegex = regexp.MustCompile(`^T([01]\d{3})([01]\d{3})?$`)
+
+// Parses the hourly temperature and dew point to a tenth of a degree,
+// e.g. T00501011 for 5.0 °C and -1.1 °C.  The dew point may be left off.
+func parsePreciseTempDew(remark string) (temperature, dewpoint *Temperature) {
+	matches := hourlyTempDewRegex.FindStringSubmatch(remark)
+	if matches == nil {
+		return
+	}
+	temperature = optional(Temperature(parseRemarkSignedValue(matches[1])))
+	if matches[2] != "" {
+		dewpoint = optional(Temperature(parseRemarkSignedValue(matches[2])))
+	}
+	return
+}
+
+func parseHourlyTempDew(remark string, units Units) (translation string) {
+	temperature, dewpoint := parsePreciseTempDew(remark)
+	if temperature == nil {
+		return ""
+	}
+	translation = "Hourly temp:  " + formatRemarkTemperature(float64(*temperature), units.Temperature)
+	if dewpoint != nil {
+		translation += ", dew point:  " + formatRemarkTemperature(float64(*dewpoint), units.Temperature)
+	}
+	return
+}
+
+// Formats a temperature in degrees Celsius in the given unit, e.g. "27.0 °C"
+func formatRemarkTemperature(celsius float64, unit string) string {
+	if unit == "" {
+		unit = "C"
+	}
+	return fmt.Sprintf("%4.1f %s", Temperature(celsius).In(unit), unitLabels[unit])
+}
+
+// Formats a pressure in hectopascals in the given unit, to the usual precision for that unit
+func formatRemarkPressure(hPa float64, unit string) string {
+	switch unit {
+	case "inHg":
+		return fmt.Sprintf("%.2f inHg", hPa/HPA_PER_INHG)
+	case "Pa":
+		return fmt.Sprintf("%.0f Pa", hPa*100)
+	}
+	return fmt.Sprintf("%.1f hPa", hPa)
+}
+
+// Formats a depth in inches in the given unit, or with " if no unit is given
+func formatRemarkDepth(format string, inches float64, unit string) string {
+	if unit == "" {
+		return fmt.Sprintf(format+"\"", inches)
+	}
+	return fmt.Sprintf(format+" %s", Height(inches/12).In(unit), unitLabels[unit])
+}
+
 func parseOneSignedFloat(signedInteger string) (value float64) {
 	value, _ = strconv.ParseFloat(signedInteger[1:], 64)
 	if signedInteger[0:1] == "1" {
@@ -153,3 +223,19 @@ func parseOneSignedFloat(signedInteger string) (value float64) {
 	}
 	return value
 }
+
+var sensorStatuses = map[string]string{
+	"$":      "Station needs maintenance",
+	"RVRNO":  "Runway visual range not available",
+	"PWINO":  "Present weather identifier not available",
+	"PNO":    "Precipitation amount not available",
+	"FZRANO": "Freezing rain sensor not available",
+	"TSNO":   "Lightning detection not available",
+	"SLPNO":  "Sea level pressure not available",
+	"VISNO":  "Secondary visibility sensor not available",
+	"CHINO":  "Secondary ceiling sensor not available",
+}
+
+func parseSensorStatus(remark string) (translation string) {
+	return sensorStatuses[remark]
+}
@@ -14,7 +14,8 @@ func TestParseRemarks(t *testing.T) {
 		RemarkTestCase{"", ""}, //empty remarks should return empty string
 		RemarkTestCase{"AO1", "AMOS station"},
 		RemarkTestCase{"AO2", "ASOS station"},
-		RemarkTestCase{"SLP123", "Sea level pressure 12.3 mb"},
+		RemarkTestCase{"SLP123", "Sea level pressure 1012.3 mb"},
+		RemarkTestCase{"SLP982", "Sea level pressure 998.2 mb"},
 		RemarkTestCase{"WEA:something", "something"},
 		RemarkTestCase{"PRESFR", "Pressure falling rapidly"},
 		RemarkTestCase{"PRESRR", "Pressure rising rapidly"},
@@ -29,9 +30,17 @@ func TestParseRemarks(t *testing.T) {
 		RemarkTestCase{"8/m", "Clouds:  Medium"},
 		RemarkTestCase{"8/h", "Clouds:  High"},
 		RemarkTestCase{"933012", "New snow coverage (water eq.):  12\""},
+		RemarkTestCase{"T00500011", "Hourly temp:   5.0 °C, dew point:   1.1 °C"},
+		RemarkTestCase{"T11021033", "Hourly temp:  -10.2 °C, dew point:  -3.3 °C"},
+		RemarkTestCase{"T0234", "Hourly temp:  23.4 °C"},
+		RemarkTestCase{"T20501011", ""},
+		RemarkTestCase{"$", "Station needs maintenance"},
+		RemarkTestCase{"PWINO", "Present weather identifier not available"},
+		RemarkTestCase{"TSNO", "Lightning detection not available"},
+		RemarkTestCase{"RVRNO", "Runway visual range not available"},
 	}
 	for _, testCase := range testCases {
-		result := parseRemark(testCase.RemarkValue)
+		result := parseRemark(testCase.RemarkValue, Units{})
 		if result != testCase.ExpectedResult {
 			t.Errorf("Invalid remark.  Expected %v, got %v", testCase.ExpectedResult, result)
 		}
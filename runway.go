@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+)
+
+// Runway visual range for one runway, e.g. R28L/2400FT or R09/0600V1200FT/U
+type RunwayVisualRange struct {
+	Runway                     string // designator, e.g. 28L
+	Min, Max                   float32
+	Unit                       string // FT or M
+	MinQualifier, MaxQualifier string // "M" for less than, "P" for greater than, or ""
+	Tendency                   string // U, D, N or ""
+}
+
+var runwayVisualRangeRegex = regexp.MustCompile(`^R(?P<runway>\d{2}[LCR]?)\/(?P<minQualifier>[MP])?(?P<min>\d{4})` +
+	`(V(?P<maxQualifier>[MP])?(?P<max>\d{4}))?(?P<unit>FT)?\/?(?P<tendency>[UDN])?$`)
+
+var runwayTendencies = map[string]string{
+	"U": "increasing",
+	"D": "decreasing",
+	"N": "no change",
+}
+
+func parseRunwayVisualRange(rangeFlat string) (runwayRange RunwayVisualRange) {
+	mappable := MappableRegexp{*runwayVisualRangeRegex}
+	matches := mappable.GetMap(rangeFlat)
+	runwayRange.Runway = matches["runway"]
+	runwayRange.MinQualifier = matches["minQualifier"]
+	runwayRange.Min = parseSignedFloat(matches["min"])
+	if matches["max"] != "" {
+		runwayRange.MaxQualifier = matches["maxQualifier"]
+		runwayRange.Max = parseSignedFloat(matches["max"])
+	} else {
+		runwayRange.MaxQualifier = runwayRange.MinQualifier
+		runwayRange.Max = runwayRange.Min
+	}
+	runwayRange.Unit = "M"
+	if matches["unit"] != "" {
+		runwayRange.Unit = matches["unit"]
+	}
+	runwayRange.Tendency = matches["tendency"]
+	return
+}
+
+// Describes the range, e.g. "runway 09 600 to 1200 feet, increasing"
+func (this RunwayVisualRange) String() string {
+	return this.Format("")
+}
+
+// Describes the range in FT or M, the unit reported if none is given
+func (this RunwayVisualRange) Format(unit string) string {
+	if unit == "" {
+		unit = this.Unit
+	}
+	min, max := this.Min, this.Max
+	if unit != this.Unit {
+		min, max = convertRange(min, this.Unit, unit), convertRange(max, this.Unit, unit)
+	}
+	unitName := "meters"
+	if unit == "FT" {
+		unitName = "feet"
+	}
+	description := fmt.Sprintf("runway %s %s", this.Runway, qualifyValue(this.MinQualifier, min))
+	if this.Max != this.Min || this.MaxQualifier != this.MinQualifier {
+		description += " to " + qualifyValue(this.MaxQualifier, max)
+	}
+	description += " " + unitName
+	if tendency, ok := runwayTendencies[this.Tendency]; ok {
+		description += ", " + tendency
+	}
+	return description
+}
+
+// Prefixes a value with its M/P qualifier in words
+func qualifyValue(qualifier string, value float32) string {
+	switch qualifier {
+	case "M":
+		return fmt.Sprintf("less than %v", value)
+	case "P":
+		return fmt.Sprintf("more than %v", value)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// Converts a range between FT and M, to the nearest whole unit
+func convertRange(value float32, from, to string) float32 {
+	if from == "FT" {
+		value = Height(value).In(to)
+	} else {
+		value = Distance(value).In(to)
+	}
+	return float32(math.Round(float64(value)))
+}
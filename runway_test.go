@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+)
+
+type RunwayTestCase struct {
+	RangeValue     string
+	Expected       RunwayVisualRange
+	ExpectedResult string
+}
+
+func TestParseRunwayVisualRange(t *testing.T) {
+	testCases := []RunwayTestCase{
+		RunwayTestCase{"R28L/2400FT", RunwayVisualRange{"28L", 2400, 2400, "FT", "", "", ""},
+			"runway 28L 2400 feet"},
+		RunwayTestCase{"R09/0600V1200FT/U", RunwayVisualRange{"09", 600, 1200, "FT", "", "", "U"},
+			"runway 09 600 to 1200 feet, increasing"},
+		RunwayTestCase{"R27/M0050N", RunwayVisualRange{"27", 50, 50, "M", "M", "M", "N"},
+			"runway 27 less than 50 meters, no change"},
+		RunwayTestCase{"R16C/1000VP6000FT", RunwayVisualRange{"16C", 1000, 6000, "FT", "", "P", ""},
+			"runway 16C 1000 to more than 6000 feet"},
+	}
+	for _, testCase := range testCases {
+		runwayRange := parseRunwayVisualRange(testCase.RangeValue)
+		if runwayRange != testCase.Expected {
+			t.Errorf("Wrong runway visual range for %v: %+v", testCase.RangeValue, runwayRange)
+		}
+		if runwayRange.String() != testCase.ExpectedResult {
+			t.Errorf("Invalid runway visual range.  Expected %v, got %v", testCase.ExpectedResult, runwayRange)
+		}
+	}
+}
+
+func TestParseMetarRunwayVisualRange(t *testing.T) {
+	const testMetar = "KORD 210051Z 15007KT 1/2SM R10L/2400FT R28R/1800V3000FT/D FG VV002 05/05 A3010"
+	metar, err := ParseMetar(testMetar, testOptions)
+	t.Logf("Received %+v, %v", metar, err)
+	if err != nil {
+		t.Error("Failed to parse but should've succeeded")
+	}
+	if len(metar.RunwayVisualRanges) != 2 || metar.RunwayVisualRanges[1].Runway != "28R" {
+		t.Errorf("Wrong runway visual ranges %+v", metar.RunwayVisualRanges)
+	}
+	if len(metar.Phenomena) != 1 {
+		t.Error("Weather after the runway visual range not decoded")
+	}
+
+	// a failed sensor's range is unavailable rather than malformed
+	metar, err = ParseMetar("KORD 210051Z 15007KT 1/2SM R06/////// R10L///// FG VV002 05/05 A3010", strictTestOptions)
+	if err != nil || len(metar.RunwayVisualRanges) != 0 || len(metar.Phenomena) != 1 {
+		t.Errorf("Unavailable runway visual ranges should be passed over %+v, %v", metar, err)
+	}
+}
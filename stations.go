@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// A reporting station and where it sits
+type Station struct {
+	Name      string
+	Elevation Height // field elevation above mean sea level
+}
+
+// Stations whose elevations are known, by ICAO identifier.  These are
+// built in; LoadStations adds the rest from a full station list.
+var stationCatalog = map[string]Station{
+	"CYYZ": {"Toronto Pearson", 569},
+	"EGLL": {"London Heathrow", 83},
+	"KABQ": {"Albuquerque Sunport", 5355},
+	"KASE": {"Aspen Pitkin County", 7820},
+	"KATL": {"Atlanta Hartsfield-Jackson", 1026},
+	"KBOS": {"Boston Logan", 20},
+	"KDEN": {"Denver International", 5434},
+	"KDFW": {"Dallas/Fort Worth", 607},
+	"KEGE": {"Eagle County Regional", 6548},
+	"KJFK": {"New York JFK", 13},
+	"KLAS": {"Las Vegas Harry Reid", 2181},
+	"KLAX": {"Los Angeles International", 128},
+	"KLXV": {"Leadville Lake County", 9934},
+	"KMDW": {"Chicago Midway", 620},
+	"KORD": {"Chicago O'Hare", 672},
+	"KPHX": {"Phoenix Sky Harbor", 1135},
+	"KPWK": {"Chicago Executive", 647},
+	"KSEA": {"Seattle-Tacoma", 433},
+	"KSFO": {"San Francisco International", 13},
+	"KSLC": {"Salt Lake City International", 4227},
+}
+
+// Looks up a station by its ICAO identifier
+func LookupStation(identifier string) (station Station, ok bool) {
+	station, ok = stationCatalog[identifier]
+	return
+}
+
+// Adds the stations in a list in the OurAirports airports.csv format to
+// the catalog, e.g. https://davidmegginson.github.io/ourairports-data/airports.csv,
+// returning how many were added.  Stations without an elevation are passed over.
+func LoadStations(input io.Reader) (count int, err error) {
+	reader := csv.NewReader(input)
+	header, err := reader.Read()
+	if err != nil {
+		return count, fmt.Errorf("unable to read station list: %v", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[name] = i
+	}
+	for _, name := range []string{"ident", "name", "elevation_ft"} {
+		if _, ok := columns[name]; !ok {
+			return count, fmt.Errorf("station list has no %s column", name)
+		}
+	}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, fmt.Errorf("unable to read station list: %v", err)
+		}
+		elevation, err := strconv.ParseFloat(record[columns["elevation_ft"]], 32)
+		if err != nil {
+			continue
+		}
+		stationCatalog[record[columns["ident"]]] = Station{record[columns["name"]], Height(elevation)}
+		count++
+	}
+}
+
+// Elevation of the reporting station, nil if it isn't in the catalog
+func (this Metar) Elevation() (elevation *Height) {
+	if station, ok := LookupStation(this.Station); ok {
+		elevation = optional(station.Elevation)
+	}
+	return
+}
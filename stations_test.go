@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadStations(t *testing.T) {
+	const stationList = `"id","ident","type","name","latitude_deg","longitude_deg","elevation_ft","gps_code"
+3465,"KBDU","small_airport","Boulder Municipal Airport",40.0394,-105.225998,5288,"KBDU"
+3754,"KORD","large_airport","Chicago O'Hare International Airport",41.9786,-87.9048,680,"KORD"
+43213,"00CO","heliport","Cass Field",40.622202,-104.34400177,,"00CO"
+`
+	defer func(catalog map[string]Station) { stationCatalog = catalog }(stationCatalog)
+	stationCatalog = map[string]Station{"KORD": stationCatalog["KORD"]}
+
+	count, err := LoadStations(strings.NewReader(stationList))
+	if err != nil || count != 2 {
+		t.Fatalf("Wrong count of stations %v, %v", count, err)
+	}
+	if station, ok := LookupStation("KBDU"); !ok || station.Name != "Boulder Municipal Airport" ||
+		station.Elevation != 5288 {
+		t.Errorf("Wrong station %+v", station)
+	}
+	// the list replaces the built-in catalog's elevations
+	if station, _ := LookupStation("KORD"); station.Elevation != 680 {
+		t.Errorf("Wrong station %+v", station)
+	}
+	if _, ok := LookupStation("00CO"); ok {
+		t.Error("Stations without an elevation should be passed over")
+	}
+
+	metar, _ := ParseMetar("KBDU 210053Z 18010KT 10SM CLR 30/05 A3012", testOptions)
+	if details := GetDetailMetar(metar); !strings.Contains(details, "Elevation     : 5288 ft\nPressure alt. : 5112 ft\n") {
+		t.Errorf("Wrong altitude details %v", details)
+	}
+}
+
+func TestLoadStationsInvalid(t *testing.T) {
+	for _, stationList := range []string{"", "\"ident\",\"name\"\nKBDU,Boulder\n"} {
+		if _, err := LoadStations(strings.NewReader(stationList)); err == nil {
+			t.Errorf("Expected an error for %q", stationList)
+		}
+	}
+}
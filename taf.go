@@ -0,0 +1,333 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// A terminal aerodrome forecast.  The embedded forecast is the one
+// prevailing from the start of the validity period, which is its period.
+type Taf struct {
+	TafForecast
+	Amended, Corrected bool
+	Nil                bool // the forecast is missing
+	Cancelled          bool // CNL, an amendment cancelling the forecast
+	Station            string
+	Issued             time.Time
+	Changes            []TafForecast // FM, BECMG, TEMPO and PROB groups in order
+	Temperatures       []TafTemperature
+	RawRemarks         string       // the text after RMK, as reported
+	Unparsed           []ParseError // tokens passed over in lenient mode
+}
+
+// Forecast conditions over a period of the forecast
+type TafForecast struct {
+	Conditions
+	Type                 string // FM, BECMG, TEMPO or PROB, empty for the base forecast
+	Probability          int    // 30 or 40 for PROB groups, including PROB30 TEMPO
+	From, Until          time.Time
+	NoSignificantWeather bool               // NSW, the end of the weather forecast before it
+	WindShear            *WindShearForecast // non-convective low-level wind shear, nil if none
+}
+
+// Low-level wind shear, e.g. WS020/24045KT, the wind at a height above the surface
+type WindShearForecast struct {
+	Wind
+	Height Height // above ground level
+}
+
+// A forecast maximum or minimum temperature, e.g. TX25/2118Z or TNM02/2206Z
+type TafTemperature struct {
+	Maximum bool
+	Value   Temperature
+	Time    time.Time
+}
+
+// Groups in the order they appear in the heading and base forecast
+var tafGroups = []metarGroup[Taf]{
+	{"report type", regexp.MustCompile(`^TAF$`),
+		regexp.MustCompile(`^TAF$`), false, nil},
+	{"modifier", regexp.MustCompile(`^(AMD|COR)$`),
+		regexp.MustCompile(`^(AMD|COR)$`), true, (*Taf).decodeModifier},
+	{"station", regexp.MustCompile(`^[A-Z][A-Z0-9]{3}$`),
+		regexp.MustCompile(`^[A-Z][A-Z0-9]{3}$`), false, (*Taf).decodeStation},
+	{"issue time", regexp.MustCompile(`^\d+Z$`),
+		dayTimeRegex, false, (*Taf).decodeIssued},
+	{"missing forecast", regexp.MustCompile(`^NIL$`),
+		regexp.MustCompile(`^NIL$`), false, (*Taf).decodeNil},
+	{"validity", regexp.MustCompile(`^\d+\/\d+$`),
+		tafPeriodRegex, false, (*Taf).decodePeriod},
+	{"cancelled", regexp.MustCompile(`^CNL$`),
+		regexp.MustCompile(`^CNL$`), false, (*Taf).decodeCancelled},
+	{"wind", regexp.MustCompile(`^[\w\/]*(KT|MPS|KMH)$`),
+		windRegex, false, (*Taf).decodeWind},
+	{"visibility", regexp.MustCompile(`^(.+[SK]M|\d{4}|CAVOK)$`),
+		visibilityRegex, false, (*Taf).decodeVisibility},
+	{"weather", regexp.MustCompile(`^([-+]|VC)?([A-Z]{2})+$`),
+		weatherRegex, true, (*Taf).decodeWeather},
+	{"weather", regexp.MustCompile(`^NSW$`),
+		regexp.MustCompile(`^NSW$`), false, (*Taf).decodeNoSignificantWeather},
+	{"clouds", regexp.MustCompile(`^(FEW|SCT|BKN|OVC|VV|SKC|NSC)`),
+		cloudLayerRegex, true, (*Taf).decodeClouds},
+	{"wind shear", regexp.MustCompile(`^WS\d`),
+		windShearForecastRegex, false, (*Taf).decodeWindShear},
+	tafTemperatureGroup,
+}
+
+// Maximum and minimum temperatures, which come at the end of the base
+// forecast or, in many ICAO forecasts, after the change groups
+var tafTemperatureGroup = metarGroup[Taf]{"temperature", regexp.MustCompile(`^T[XN]`),
+	tafTemperatureRegex, true, (*Taf).decodeTemperature}
+
+// Groups in the order they appear within a change group, after its type
+var tafChangeGroups = []metarGroup[TafForecast]{
+	{"change period", regexp.MustCompile(`^\d+\/\d+$`),
+		tafPeriodRegex, false, (*TafForecast).decodePeriod},
+	{"wind", regexp.MustCompile(`^[\w\/]*(KT|MPS|KMH)$`),
+		windRegex, false, (*TafForecast).decodeWind},
+	{"visibility", regexp.MustCompile(`^(.+[SK]M|\d{4}|CAVOK)$`),
+		visibilityRegex, false, (*TafForecast).decodeVisibility},
+	{"weather", regexp.MustCompile(`^([-+]|VC)?([A-Z]{2})+$`),
+		weatherRegex, true, (*TafForecast).decodeWeather},
+	{"weather", regexp.MustCompile(`^NSW$`),
+		regexp.MustCompile(`^NSW$`), false, (*TafForecast).decodeNoSignificantWeather},
+	{"clouds", regexp.MustCompile(`^(FEW|SCT|BKN|OVC|VV|SKC|NSC)`),
+		cloudLayerRegex, true, (*TafForecast).decodeClouds},
+	{"wind shear", regexp.MustCompile(`^WS\d`),
+		windShearForecastRegex, false, (*TafForecast).decodeWindShear},
+}
+
+var tafPeriodRegex = regexp.MustCompile(`^(\d{2})(\d{2})\/(\d{2})(\d{2})$`)
+
+var tafChangeRegex = regexp.MustCompile(`^(FM\d{6}|BECMG|TEMPO|PROB\d{2})$`)
+
+var windShearForecastRegex = regexp.MustCompile(`^WS(\d{3})\/(` + strings.Trim(windRegex.String(), "^$") + `)$`)
+
+var tafTemperatureRegex = regexp.MustCompile(`^T([XN])(M?\d{2})\/(\d{2})(\d{2})Z$`)
+
+// Walks the forecast one token at a time like ParseMetar, splitting it
+// into the base forecast and its change groups.  Times are resolved
+// against the issue time, which is resolved against the reference.
+func ParseTaf(flatTaf string, options ParseOptions) (taf Taf, err error) {
+	flatTaf = strings.TrimRight(strings.TrimRightFunc(flatTaf, unicode.IsSpace), "=")
+	tokens := tokenize(flatTaf)
+	for i, token := range tokens {
+		if token.value == "RMK" {
+			taf.RawRemarks = strings.TrimSpace(flatTaf[token.offset+len(token.value):])
+			tokens = tokens[:i]
+			break
+		}
+	}
+	end := len(tokens)
+	for end > 0 && tafTemperatureGroup.pattern.MatchString(tokens[end-1].value) {
+		end--
+	}
+	tokens, temperatures := tokens[:end], tokens[end:]
+
+	sections := splitTafChanges(tokens)
+	taf.Unparsed, err = decodeGroups(&taf, tafGroups, sections[0], options.Mode)
+	if err != nil {
+		return taf, err
+	}
+	if taf.Station == "" {
+		return taf, &ParseError{"station", "", 0, "no station identifier found"}
+	}
+	if taf.Issued.IsZero() {
+		return taf, &ParseError{"issue time", "", 0, "no issue time found"}
+	}
+	day := taf.Issued.Day()
+	var resolved bool
+	if taf.Issued, resolved = resolveDayTime(int32(day), taf.Issued, options.reference()); !resolved {
+		return taf, &ParseError{"issue time", "", 0, fmt.Sprintf("no recent month has a day %d", day)}
+	}
+	if !taf.From.IsZero() {
+		taf.From = resolveForecastTime(taf.From, taf.Issued)
+		taf.Until = resolveForecastTime(taf.Until, taf.Issued)
+	}
+
+	for _, section := range sections[1:] {
+		change, unparsed, err := parseTafChange(section, taf.Issued, options.Mode)
+		taf.Changes = append(taf.Changes, change)
+		taf.Unparsed = append(taf.Unparsed, unparsed...)
+		if err != nil {
+			return taf, err
+		}
+	}
+	unparsed, err := decodeGroups(&taf, []metarGroup[Taf]{tafTemperatureGroup}, temperatures, options.Mode)
+	taf.Unparsed = append(taf.Unparsed, unparsed...)
+	if err != nil {
+		return taf, err
+	}
+	for i, temperature := range taf.Temperatures {
+		taf.Temperatures[i].Time = resolveForecastTime(temperature.Time, taf.Issued)
+	}
+	// an FM group lasts until the next one, or the end of the forecast
+	until := taf.Until
+	for i := len(taf.Changes) - 1; i >= 0; i-- {
+		if taf.Changes[i].Type == "FM" {
+			taf.Changes[i].Until = until
+			until = taf.Changes[i].From
+		}
+	}
+	return taf, nil
+}
+
+// Splits the tokens into the heading and base forecast followed by each
+// change group, a change group starting with its type.  PROB30 TEMPO is
+// a single change group.
+func splitTafChanges(tokens []metarToken) (sections [][]metarToken) {
+	start := 0
+	for i, token := range tokens {
+		if !tafChangeRegex.MatchString(token.value) {
+			continue
+		}
+		if token.value == "TEMPO" && i > 0 && strings.HasPrefix(tokens[i-1].value, "PROB") {
+			continue
+		}
+		sections = append(sections, tokens[start:i])
+		start = i
+	}
+	return append(sections, tokens[start:])
+}
+
+// Decodes a change group, the first token or two being its type
+func parseTafChange(tokens []metarToken, issued time.Time,
+	mode ParseMode) (change TafForecast, unparsed []ParseError, err error) {
+	kind := tokens[0].value
+	tokens = tokens[1:]
+	switch {
+	case strings.HasPrefix(kind, "FM"):
+		change.Type = "FM"
+		change.From = parseDayHour(kind[2:])
+	case strings.HasPrefix(kind, "PROB"):
+		change.Type = "PROB"
+		change.Probability, _ = strconv.Atoi(kind[4:])
+		if len(tokens) > 0 && tokens[0].value == "TEMPO" {
+			change.Type = "TEMPO"
+			tokens = tokens[1:]
+		}
+	default:
+		change.Type = kind
+	}
+	unparsed, err = decodeGroups(&change, tafChangeGroups, tokens, mode)
+	if !change.From.IsZero() {
+		change.From = resolveForecastTime(change.From, issued)
+	}
+	if !change.Until.IsZero() {
+		change.Until = resolveForecastTime(change.Until, issued)
+	}
+	return
+}
+
+func (this *Taf) decodeModifier(value string) {
+	this.Amended = this.Amended || value == "AMD"
+	this.Corrected = this.Corrected || value == "COR"
+}
+
+func (this *Taf) decodeStation(value string) {
+	this.Station = value
+}
+
+// Keeps the day and time of day until the issue time is resolved
+func (this *Taf) decodeIssued(value string) {
+	this.Issued = parseDayHour(value[:6])
+}
+
+func (this *Taf) decodeNil(value string) {
+	this.Nil = true
+}
+
+func (this *Taf) decodeCancelled(value string) {
+	this.Cancelled = true
+}
+
+func (this *Taf) decodeTemperature(value string) {
+	matches := tafTemperatureRegex.FindStringSubmatch(value)
+	this.Temperatures = append(this.Temperatures, TafTemperature{
+		Maximum: matches[1] == "X",
+		Value:   Temperature(parseSignedFloat(matches[2])),
+		Time:    parseDayHour(matches[3] + matches[4]),
+	})
+}
+
+// Keeps the days and hours of the period until the issue time is resolved
+func (this *TafForecast) decodePeriod(value string) {
+	matches := tafPeriodRegex.FindStringSubmatch(value)
+	this.From = parseDayHour(matches[1] + matches[2])
+	this.Until = parseDayHour(matches[3] + matches[4])
+}
+
+func (this *TafForecast) decodeNoSignificantWeather(value string) {
+	this.NoSignificantWeather = true
+}
+
+func (this *TafForecast) decodeWindShear(value string) {
+	matches := windShearForecastRegex.FindStringSubmatch(value)
+	this.WindShear = &WindShearForecast{parseWind(matches[2]), Height(parseSignedFloat(matches[1]) * 100)}
+}
+
+// Parses a day and hour, and optionally minutes, e.g. 2118 or 220030,
+// into a time in January 2000 to be resolved later.  Hour 24 is
+// midnight at the end of the day.
+func parseDayHour(dayHourFlat string) time.Time {
+	day, _ := strconv.Atoi(dayHourFlat[:2])
+	hour, _ := strconv.Atoi(dayHourFlat[2:4])
+	minute := 0
+	if len(dayHourFlat) == 6 {
+		minute, _ = strconv.Atoi(dayHourFlat[4:])
+	}
+	return time.Date(2000, time.January, day, hour, minute, 0, 0, time.UTC)
+}
+
+// How far a forecast time may be from the issue time
+const FORECAST_SPAN = 15 * 24 * time.Hour
+
+// Resolves a day and time from parseDayHour into the UTC time within
+// FORECAST_SPAN of the issue time, rolling over months and years.  A day
+// past the end of the month, such as 3024 in April, rolls into the next.
+func resolveForecastTime(unresolved time.Time, issued time.Time) (resolved time.Time) {
+	for _, months := range []int{0, 1, -1} {
+		resolved = time.Date(issued.Year(), issued.Month()+time.Month(months), unresolved.Day(),
+			unresolved.Hour(), unresolved.Minute(), 0, 0, time.UTC)
+		if resolved.Sub(issued).Abs() < FORECAST_SPAN {
+			return resolved
+		}
+	}
+	return
+}
+
+// Describes the type and period of the forecast, e.g. "30% chance temporarily from Jan 22 10:00 until Jan 22 14:00"
+func (this TafForecast) Period() string {
+	const timeFormat = "Jan 2 15:04"
+	from, until := this.From.Format(timeFormat), this.Until.Format(timeFormat)
+	var period string
+	switch this.Type {
+	case "BECMG":
+		period = fmt.Sprintf("becoming between %s and %s", from, until)
+	case "TEMPO":
+		period = fmt.Sprintf("temporarily from %s until %s", from, until)
+	default:
+		period = fmt.Sprintf("from %s until %s", from, until)
+	}
+	if this.Probability != 0 {
+		period = fmt.Sprintf("%d%% chance %s", this.Probability, period)
+	}
+	return period
+}
+
+// Describes the temperature in the given unit, e.g. "maximum 25 °C at Jan 21 18:00"
+func (this TafTemperature) Format(unit string) string {
+	kind := "minimum"
+	if this.Maximum {
+		kind = "maximum"
+	}
+	return fmt.Sprintf("%s %s at %s", kind, this.Value.Format(unit), this.Time.Format("Jan 2 15:04"))
+}
+
+func (this TafTemperature) String() string {
+	return this.Format("")
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTaf(t *testing.T) {
+	const testTaf = "TAF KORD 211720Z 2118/2224 19012KT P6SM SCT050 WS020/24045KT " +
+		"FM220000 20008KT P6SM BKN080 " +
+		"TEMPO 2204/2208 3SM -SHRA BKN030 " +
+		"PROB30 2210/2214 1SM TSRA OVC010CB " +
+		"FM221500 31015G25KT 5SM BR OVC015 " +
+		"BECMG 2218/2220 P6SM NSW SCT040="
+	taf, err := ParseTaf(testTaf, testOptions)
+	t.Logf("Received %+v, %v", taf, err)
+	if err != nil {
+		t.Fatalf("Failed to parse but should've succeeded: %v", err)
+	}
+	if taf.Station != "KORD" || !taf.Issued.Equal(time.Date(2013, time.January, 21, 17, 20, 0, 0, time.UTC)) {
+		t.Errorf("Wrong heading %v %v", taf.Station, taf.Issued)
+	}
+	if !taf.From.Equal(time.Date(2013, time.January, 21, 18, 0, 0, 0, time.UTC)) ||
+		!taf.Until.Equal(time.Date(2013, time.January, 23, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Wrong validity %v to %v", taf.From, taf.Until)
+	}
+	if *taf.WindSpeed != 12 || taf.Visibility.Qualifier != "P" || len(taf.Clouds) != 1 {
+		t.Error("Wrong base forecast")
+	}
+	if taf.WindShear == nil || taf.WindShear.Height != 2000 || *taf.WindShear.WindSpeed != 45 {
+		t.Errorf("Wrong wind shear %+v", taf.WindShear)
+	}
+	if len(taf.Changes) != 5 {
+		t.Fatalf("Received wrong count of changes %v", len(taf.Changes))
+	}
+
+	from := taf.Changes[0]
+	if from.Type != "FM" || !from.From.Equal(time.Date(2013, time.January, 22, 0, 0, 0, 0, time.UTC)) ||
+		!from.Until.Equal(taf.Changes[3].From) {
+		t.Errorf("Wrong FM group %+v", from)
+	}
+	if taf.Changes[3].Until != taf.Until {
+		t.Error("Last FM group should last until the end of the forecast")
+	}
+	temporary := taf.Changes[1]
+	if temporary.Type != "TEMPO" || temporary.From.Hour() != 4 || temporary.Until.Hour() != 8 ||
+		temporary.Phenomena.String() != "light rain showers" {
+		t.Errorf("Wrong TEMPO group %+v", temporary)
+	}
+	probable := taf.Changes[2]
+	if probable.Type != "PROB" || probable.Probability != 30 || probable.Clouds[0].Convective != "CB" {
+		t.Errorf("Wrong PROB group %+v", probable)
+	}
+	becoming := taf.Changes[4]
+	if becoming.Type != "BECMG" || !becoming.NoSignificantWeather || becoming.Period() !=
+		"becoming between Jan 22 18:00 and Jan 22 20:00" {
+		t.Errorf("Wrong BECMG group %+v", becoming)
+	}
+
+	details := GetDetailTaf(taf)
+	t.Logf("Details: %v", details)
+	for _, line := range []string{
+		"Initially     : from Jan 21 18:00 until Jan 23 00:00, wind 190 (S) at 12 KT, visibility more than 6 miles, " +
+			"clouds SCT at 5000 ft, wind shear at 2000 ft 240 (WSW) at 45 KT\n",
+		"Change        : 30% chance from Jan 22 10:00 until Jan 22 14:00, visibility 1 miles, " +
+			"thunderstorm with rain, clouds OVC at 1000 ft CB\n",
+	} {
+		if !strings.Contains(details, line) {
+			t.Errorf("Details missing %q", line)
+		}
+	}
+}
+
+func TestParseTafInternational(t *testing.T) {
+	const testTaf = "TAF AMD EGLL 302300Z 3100/0106 24010KT 9999 SCT030 TX08/3114Z TNM02/0106Z " +
+		"PROB40 TEMPO 3103/3106 4000 RADZ BKN010 BECMG 3118/3124 VRB03KT CAVOK"
+	taf, err := ParseTaf(testTaf, testOptions)
+	t.Logf("Received %+v, %v", taf, err)
+	if err != nil {
+		t.Fatalf("Failed to parse but should've succeeded: %v", err)
+	}
+	if !taf.Amended || !taf.Until.Equal(time.Date(2013, time.February, 1, 6, 0, 0, 0, time.UTC)) {
+		t.Errorf("Wrong heading %+v", taf)
+	}
+	if len(taf.Temperatures) != 2 || !taf.Temperatures[0].Maximum || taf.Temperatures[0].Value != 8 ||
+		taf.Temperatures[1].Value != -2 || taf.Temperatures[1].Time.Month() != time.February {
+		t.Errorf("Wrong temperatures %+v", taf.Temperatures)
+	}
+	if taf.Temperatures[1].String() != "minimum -2 °C at Feb 1 06:00" {
+		t.Errorf("Wrong temperature description %v", taf.Temperatures[1])
+	}
+	if len(taf.Changes) != 2 {
+		t.Fatalf("Received wrong count of changes %v", len(taf.Changes))
+	}
+	if taf.Changes[0].Type != "TEMPO" || taf.Changes[0].Probability != 40 {
+		t.Errorf("Wrong PROB40 TEMPO group %+v", taf.Changes[0])
+	}
+	// hour 24 is midnight at the end of the day
+	if !taf.Changes[1].Until.Equal(time.Date(2013, time.February, 1, 0, 0, 0, 0, time.UTC)) ||
+		!taf.Changes[1].Visibility.CAVOK {
+		t.Errorf("Wrong BECMG group %+v", taf.Changes[1])
+	}
+}
+
+func TestParseTafTrailingTemperatures(t *testing.T) {
+	const testTaf = "TAF EGLL 302300Z 3100/0106 24010KT 9999 SCT030 " +
+		"TEMPO 3103/3106 4000 RADZ BKN010 BECMG 3118/3124 VRB03KT CAVOK TX08/3114Z TNM02/0106Z"
+	taf, err := ParseTaf(testTaf, strictTestOptions)
+	if err != nil {
+		t.Fatalf("Failed to parse but should've succeeded: %v", err)
+	}
+	if len(taf.Temperatures) != 2 || taf.Temperatures[0].Value != 8 ||
+		!taf.Temperatures[1].Time.Equal(time.Date(2013, time.February, 1, 6, 0, 0, 0, time.UTC)) {
+		t.Errorf("Wrong temperatures %+v", taf.Temperatures)
+	}
+	if len(taf.Changes) != 2 || !taf.Changes[1].Visibility.CAVOK {
+		t.Errorf("Wrong changes %+v", taf.Changes)
+	}
+
+	_, err = ParseTaf(strings.Replace(testTaf, "TX08", "TX8", 1), strictTestOptions)
+	if parseErr, ok := err.(*ParseError); !ok || parseErr.Group != "temperature" || parseErr.Token != "TX8/3114Z" {
+		t.Errorf("Wrong error %v", err)
+	}
+}
+
+func TestParseTafNilAndCancelled(t *testing.T) {
+	taf, err := ParseTaf("TAF KXYZ 211720Z NIL=", testOptions)
+	if err != nil || !taf.Nil || !taf.From.IsZero() {
+		t.Errorf("Wrong missing forecast %+v, %v", taf, err)
+	}
+	if !strings.Contains(GetDetailTaf(taf), "Forecast      : TAF, missing\n") {
+		t.Error("Wrong details for missing forecast")
+	}
+	taf, err = ParseTaf("TAF AMD KXYZ 211920Z 2118/2224 CNL", testOptions)
+	if err != nil || !taf.Cancelled || !taf.Amended {
+		t.Errorf("Wrong cancelled forecast %+v, %v", taf, err)
+	}
+}
+
+func TestParseTafMalformedGroup(t *testing.T) {
+	const testTaf = "TAF KORD 211720Z 2118/2224 19012KT P6SM SCT050 TEMPO 2204/228 3SM -SHRA"
+	_, err := ParseTaf(testTaf, strictTestOptions)
+	parseErr, ok := err.(*ParseError)
+	if !ok || parseErr.Group != "change period" || parseErr.Token != "2204/228" || parseErr.Offset != 53 {
+		t.Errorf("Wrong error %v", err)
+	}
+	taf, err := ParseTaf(testTaf, testOptions)
+	if err != nil || len(taf.Unparsed) != 1 || len(taf.Changes) != 1 {
+		t.Errorf("Wrong lenient parse %+v, %v", taf, err)
+	}
+}
+
+func TestResolveForecastTime(t *testing.T) {
+	issued := time.Date(2013, time.April, 30, 17, 20, 0, 0, time.UTC)
+	if resolved := resolveForecastTime(parseDayHour("3024"), issued); !resolved.Equal(
+		time.Date(2013, time.May, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Wrong end of month %v", resolved)
+	}
+	if resolved := resolveForecastTime(parseDayHour("0106"), issued); !resolved.Equal(
+		time.Date(2013, time.May, 1, 6, 0, 0, 0, time.UTC)) {
+		t.Errorf("Wrong next month %v", resolved)
+	}
+	issued = time.Date(2013, time.January, 1, 0, 20, 0, 0, time.UTC)
+	if resolved := resolveForecastTime(parseDayHour("3122"), issued); !resolved.Equal(
+		time.Date(2012, time.December, 31, 22, 0, 0, 0, time.UTC)) {
+		t.Errorf("Wrong previous year %v", resolved)
+	}
+}
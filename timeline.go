@@ -0,0 +1,84 @@
+package main
+
+import (
+	"time"
+)
+
+// The forecast for one hour of a TAF's validity period
+type ForecastHour struct {
+	Time       time.Time
+	Prevailing Metar                // conditions after the FM and BECMG groups in effect
+	Possible   []PossibleConditions // TEMPO and PROB groups, and BECMG groups still changing
+}
+
+// Conditions that may occur during an hour instead of the prevailing ones
+type PossibleConditions struct {
+	Metar              // the prevailing conditions with the change applied
+	Change TafForecast // the group that may apply
+}
+
+// Expands the forecast into a snapshot for each hour of the validity
+// period.  FM groups replace the prevailing conditions from their start,
+// and BECMG groups change them once their period is over.  Until then a
+// BECMG group, and any TEMPO or PROB group during its period, only
+// possibly applies.
+func (this Taf) Timeline() (hours []ForecastHour) {
+	if this.Nil || this.Cancelled || this.From.IsZero() {
+		return
+	}
+	for hour := this.From.Truncate(time.Hour); hour.Before(this.Until); hour = hour.Add(time.Hour) {
+		prevailing := this.Conditions
+		var possible []TafForecast
+		for _, change := range this.Changes {
+			switch {
+			case change.From.After(hour):
+				continue
+			case change.Type == "FM":
+				prevailing = change.Conditions
+				possible = nil
+			case change.Type == "BECMG" && !change.Until.After(hour):
+				prevailing = applyChange(prevailing, change)
+			case change.Until.After(hour):
+				possible = append(possible, change)
+			}
+		}
+
+		forecastHour := ForecastHour{Time: hour, Prevailing: this.snapshot(prevailing, hour)}
+		for _, change := range possible {
+			forecastHour.Possible = append(forecastHour.Possible,
+				PossibleConditions{this.snapshot(applyChange(prevailing, change), hour), change})
+		}
+		hours = append(hours, forecastHour)
+	}
+	return
+}
+
+// Builds an observation-like report of the conditions at the station
+func (this Taf) snapshot(conditions Conditions, at time.Time) Metar {
+	return Metar{Conditions: conditions, Station: this.Station, Time: at, Day: int32(at.Day())}
+}
+
+// Applies the groups a change forecasts over the conditions, keeping
+// whatever it leaves out.  NSW ends the weather.
+func applyChange(conditions Conditions, change TafForecast) Conditions {
+	if change.WindUnit != "" {
+		conditions.Wind = change.Wind
+	}
+	if change.Visibility != nil {
+		conditions.Visibility = change.Visibility
+	}
+	if change.NoSignificantWeather {
+		conditions.Phenomena = nil
+	}
+	if len(change.Phenomena) != 0 {
+		conditions.Phenomena = change.Phenomena
+	}
+	if len(change.Clouds) != 0 {
+		conditions.Clouds = change.Clouds
+	}
+	// CAVOK also means no weather or cloud of significance
+	if change.Visibility != nil && change.Visibility.CAVOK {
+		conditions.Phenomena, conditions.Clouds = nil, nil
+	}
+	return conditions
+}
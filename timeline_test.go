@@ -0,0 +1,113 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testTimelineTaf = "TAF KORD 211720Z 2118/2206 19012KT P6SM SCT050 " +
+	"BECMG 2119/2121 22015KT BKN040 " +
+	"TEMPO 2122/2201 3SM -SHRA BKN030 " +
+	"FM220200 31015G25KT 5SM BR OVC015 " +
+	"PROB30 TEMPO 2203/2205 1SM TSRA OVC010CB"
+
+func TestTafTimeline(t *testing.T) {
+	taf, err := ParseTaf(testTimelineTaf, testOptions)
+	if err != nil {
+		t.Fatalf("Failed to parse but should've succeeded: %v", err)
+	}
+	hours := taf.Timeline()
+	if len(hours) != 12 {
+		t.Fatalf("Received wrong count of hours %v", len(hours))
+	}
+	if !hours[0].Time.Equal(time.Date(2013, time.January, 21, 18, 0, 0, 0, time.UTC)) ||
+		hours[0].Prevailing.Station != "KORD" || len(hours[0].Possible) != 0 {
+		t.Errorf("Wrong first hour %+v", hours[0])
+	}
+
+	type hourTestCase struct {
+		Hour               int
+		ExpectedWindSpeed  Speed
+		ExpectedClouds     string
+		ExpectedPossible   int
+		ExpectedPhenomena  string
+		ExpectedVisibility string
+	}
+	testCases := []hourTestCase{
+		{0, 12, "SCT050", 0, "", "more than 6 miles"},
+		// becoming, the new conditions are possible
+		{1, 12, "SCT050", 1, "", "more than 6 miles"},
+		// once it has become
+		{3, 15, "BKN040", 0, "", "more than 6 miles"},
+		{4, 15, "BKN040", 1, "", "more than 6 miles"},
+		{6, 15, "BKN040", 1, "", "more than 6 miles"},
+		// from 02:00 everything is replaced
+		{8, 15, "OVC015", 0, "mist", "5 miles"},
+		{9, 15, "OVC015", 1, "mist", "5 miles"},
+		{11, 15, "OVC015", 0, "mist", "5 miles"},
+	}
+	for _, testCase := range testCases {
+		hour := hours[testCase.Hour]
+		prevailing := hour.Prevailing
+		clouds := strings.Join(encodeClouds(prevailing.Clouds), " ")
+		if *prevailing.WindSpeed != testCase.ExpectedWindSpeed || clouds != testCase.ExpectedClouds ||
+			prevailing.Phenomena.String() != testCase.ExpectedPhenomena ||
+			prevailing.Visibility.String() != testCase.ExpectedVisibility ||
+			len(hour.Possible) != testCase.ExpectedPossible {
+			t.Errorf("Wrong forecast for %v: %+v", hour.Time, hour)
+		}
+	}
+
+	temporary := hours[4].Possible[0]
+	if temporary.Change.Type != "TEMPO" || temporary.Visibility.String() != "3 miles" ||
+		temporary.Phenomena.String() != "light rain showers" || *temporary.WindSpeed != 15 {
+		t.Errorf("Wrong possible conditions %+v", temporary)
+	}
+}
+
+func TestTafTimelineNoSignificantWeather(t *testing.T) {
+	taf, _ := ParseTaf("TAF EGLL 211100Z 2112/2115 24010KT 4000 -RA BKN010 BECMG 2112/2113 9999 NSW", testOptions)
+	hours := taf.Timeline()
+	if len(hours) != 3 || len(hours[0].Prevailing.Phenomena) != 1 || len(hours[1].Prevailing.Phenomena) != 0 ||
+		hours[1].Prevailing.Visibility.Meters != 10000 || len(hours[1].Prevailing.Clouds) != 1 {
+		t.Errorf("Wrong timeline %+v", hours)
+	}
+	taf, _ = ParseTaf("TAF KXYZ 211720Z NIL", testOptions)
+	if len(taf.Timeline()) != 0 {
+		t.Error("Missing forecast should have no timeline")
+	}
+}
+
+func TestTafTimelineTable(t *testing.T) {
+	taf, _ := ParseTaf(testTimelineTaf, testOptions)
+	table := GetTafTimeline(taf)
+	t.Logf("Table:\n%v", table)
+	lines := strings.Split(strings.TrimSpace(table), "\n")
+	if len(lines) != 13 || !strings.HasPrefix(lines[0], "Time (UTC)") {
+		t.Fatalf("Wrong table layout")
+	}
+	for _, expected := range []string{"Jan 21 22:00", "22015KT", "P6SM", "BKN040", "TEMPO 3SM -SHRA BKN030"} {
+		if !strings.Contains(lines[5], expected) {
+			t.Errorf("Row missing %q: %v", expected, lines[5])
+		}
+	}
+	if !strings.Contains(lines[10], "PROB30 TEMPO 1SM TSRA OVC010CB") {
+		t.Errorf("Wrong PROB30 TEMPO row %v", lines[10])
+	}
+
+	// described in the selected units rather than as report code
+	unitSystem = "metric"
+	defer func() { unitSystem = "" }()
+	lines = strings.Split(strings.TrimSpace(GetTafTimeline(taf)), "\n")
+	t.Logf("Table:\n%v", strings.Join(lines, "\n"))
+	for _, expected := range []string{"220 (SW) at 27.8 KMH", "more than 9.7 kilometers", "BKN at 1219.2 m",
+		"TEMPO visibility 4828 meters, light rain showers, clouds BKN at 914.4 m"} {
+		if !strings.Contains(lines[5], expected) {
+			t.Errorf("Row missing %q: %v", expected, lines[5])
+		}
+	}
+	if !strings.Contains(lines[9], "8 kilometers") || !strings.Contains(lines[9], "mist") {
+		t.Errorf("Wrong metric row %v", lines[9])
+	}
+}
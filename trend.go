@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// A TREND forecast appended to a report: NOSIG, or a BECMG or TEMPO change group
+type Trend struct {
+	Conditions
+	Type                 string     // NOSIG, BECMG or TEMPO
+	From, Until, At      *time.Time // validity times, nil when not given
+	NoSignificantWeather bool       // NSW, the end of the weather reported in the observation
+}
+
+// Groups in the order they appear within a change group
+var trendGroups = []metarGroup[Trend]{
+	{"trend time", regexp.MustCompile(`^(FM|TL|AT)\d+$`),
+		regexp.MustCompile(`^(FM|TL|AT)\d{4}$`), true, (*Trend).decodeTime},
+	{"wind", regexp.MustCompile(`^\w*(KT|MPS|KMH)$`),
+		windRegex, false, (*Trend).decodeWind},
+	{"visibility", regexp.MustCompile(`^(.+[SK]M|\d{4}|CAVOK)$`),
+		visibilityRegex, false, (*Trend).decodeVisibility},
+	{"weather", regexp.MustCompile(`^([-+]|VC)?([A-Z]{2})+$`),
+		weatherRegex, true, (*Trend).decodeWeather},
+	{"weather", regexp.MustCompile(`^NSW$`),
+		regexp.MustCompile(`^NSW$`), false, (*Trend).decodeNoSignificantWeather},
+	{"clouds", regexp.MustCompile(`^(FEW|SCT|BKN|OVC|VV|\/\/\/|SKC|CLR|NSC|NCD)`),
+		cloudLayerRegex, true, (*Trend).decodeClouds},
+}
+
+var trendTypes = map[string]string{
+	"NOSIG": "no significant change",
+	"BECMG": "becoming",
+	"TEMPO": "temporarily",
+}
+
+// Splits the tokens before RMK into the observation itself followed by
+// each of its change groups, a change group starting with its type
+func splitTrends(tokens []metarToken) (sections [][]metarToken) {
+	start := 0
+	for i, token := range tokens {
+		if _, ok := trendTypes[token.value]; ok {
+			sections = append(sections, tokens[start:i])
+			start = i
+		}
+	}
+	return append(sections, tokens[start:])
+}
+
+// Decodes a change group, the first token being its type.  Validity
+// times are resolved to the first occurrence after the observation.
+func parseTrend(tokens []metarToken, observed time.Time,
+	mode ParseMode) (trend Trend, unparsed []ParseError, err error) {
+	trend.Type = tokens[0].value
+	unparsed, err = decodeGroups(&trend, trendGroups, tokens[1:], mode)
+	for _, validity := range []*time.Time{trend.From, trend.Until, trend.At} {
+		if validity != nil {
+			*validity = resolveTimeOfDay(*validity, observed)
+		}
+	}
+	return
+}
+
+func (this *Trend) decodeTime(value string) {
+	validity, _ := time.Parse("1504", value[2:])
+	switch value[:2] {
+	case "FM":
+		this.From = &validity
+	case "TL":
+		this.Until = &validity
+	case "AT":
+		this.At = &validity
+	}
+}
+
+func (this *Trend) decodeNoSignificantWeather(value string) {
+	this.NoSignificantWeather = true
+}
+
+// Describes the type and validity of the change, e.g. "becoming from 11:00 until 12:00"
+func (this Trend) Period() string {
+	period := trendTypes[this.Type]
+	if this.From != nil {
+		period += fmt.Sprintf(" from %s", this.From.Format("15:04"))
+	}
+	if this.Until != nil {
+		period += fmt.Sprintf(" until %s", this.Until.Format("15:04"))
+	}
+	if this.At != nil {
+		period += fmt.Sprintf(" at %s", this.At.Format("15:04"))
+	}
+	return period
+}
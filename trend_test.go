@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMetarTrends(t *testing.T) {
+	const testMetar = "EGLL 210050Z 24010KT 9999 SCT030 08/04 Q1012 " +
+		"BECMG FM1100 TL1200 27015G25KT 3000 -SHRA BKN015 TEMPO 4000 NSW NSC"
+	metar, err := ParseMetar(testMetar, testOptions)
+	t.Logf("Received %+v, %v", metar, err)
+	if err != nil {
+		t.Error("Failed to parse but should've succeeded")
+	}
+	if len(metar.Trends) != 2 {
+		t.Fatalf("Received wrong count of trends %v", len(metar.Trends))
+	}
+	becoming := metar.Trends[0]
+	if becoming.Type != "BECMG" || becoming.From == nil ||
+		!becoming.From.Equal(time.Date(2013, time.January, 21, 11, 0, 0, 0, time.UTC)) ||
+		becoming.Until == nil || becoming.Until.Hour() != 12 || becoming.At != nil {
+		t.Errorf("Wrong change group %+v", becoming)
+	}
+	if *becoming.WindSpeed != 15 || *becoming.WindGust != 25 || becoming.Visibility.Meters != 3000 {
+		t.Error("Wrong forecast wind or visibility")
+	}
+	if becoming.Phenomena.String() != "light rain showers" || len(becoming.Clouds) != 1 {
+		t.Error("Wrong forecast weather or clouds")
+	}
+	temporary := metar.Trends[1]
+	if temporary.Type != "TEMPO" || !temporary.NoSignificantWeather || temporary.Clouds[0].Coverage != "NSC" {
+		t.Errorf("Wrong change group %+v", temporary)
+	}
+	if len(metar.Clouds) != 1 || metar.Visibility.Meters != 10000 {
+		t.Error("Change groups leaked into the observation")
+	}
+
+	details := GetDetailMetar(metar)
+	t.Logf("Details: %v", details)
+	if !strings.Contains(details, "Trend         : becoming from 11:00 until 12:00, wind 270 (W) at 15 KT gusting 25 KT, "+
+		"visibility 3000 meters, light rain showers, clouds BKN at 1500 ft\n") {
+		t.Error("Wrong trend details")
+	}
+}
+
+func TestParseMetarNoSignificantChange(t *testing.T) {
+	const testMetar = "EGLL 210050Z 24010KT 9999 SCT030 08/04 Q1012 NOSIG RMK AO2"
+	metar, err := ParseMetar(testMetar, testOptions)
+	if err != nil {
+		t.Error("Failed to parse but should've succeeded")
+	}
+	if len(metar.Trends) != 1 || metar.Trends[0].Period() != "no significant change" {
+		t.Errorf("Wrong trends %+v", metar.Trends)
+	}
+	if len(metar.Remarks) != 1 {
+		t.Error("Remarks after the trend not decoded")
+	}
+}
+
+func TestParseMetarRecentWeatherAndWindShear(t *testing.T) {
+	const testMetar = "LOWW 210050Z 30012KT 9999 FEW040 12/06 Q1008 RERA RETS WS R27 WS ALL RWY NOSIG"
+	metar, err := ParseMetar(testMetar, testOptions)
+	t.Logf("Received %+v, %v", metar, err)
+	if err != nil {
+		t.Error("Failed to parse but should've succeeded")
+	}
+	if metar.RecentWeather.String() != "rain, thunderstorm" {
+		t.Errorf("Wrong recent weather %v", metar.RecentWeather)
+	}
+	if len(metar.WindShear) != 2 || metar.WindShear[0] != "27" || metar.WindShear[1] != "ALL" {
+		t.Errorf("Wrong wind shear %v", metar.WindShear)
+	}
+
+	details := GetDetailMetar(metar)
+	if !strings.Contains(details, "Wind shear    : runway 27, all runways\n") {
+		t.Errorf("Wrong wind shear details %v", details)
+	}
+
+	// without the groups that usually come before it
+	metar, err = ParseMetar("KORD 210051Z A//// RERA", strictTestOptions)
+	if err != nil || len(metar.Phenomena) != 0 || metar.RecentWeather.String() != "rain" {
+		t.Errorf("Wrong recent weather %+v, %v", metar, err)
+	}
+	if _, err = ParseMetar(EncodeMetar(metar), strictTestOptions); err != nil {
+		t.Errorf("Encoding of recent weather isn't well-formed: %v", err)
+	}
+}
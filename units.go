@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+const KNOTS_PER_MPS = 1.943844
+const KNOTS_PER_KMH = 1 / 1.852
+const KNOTS_PER_MPH = 0.868976
+const METERS_PER_FOOT = 0.3048
+
+// A temperature in degrees Celsius
+type Temperature float32
+
+// A speed in knots
+type Speed float32
+
+// A distance along the ground, such as a visibility, in meters
+type Distance float32
+
+// A height, such as a cloud base, in feet.  Depths of precipitation
+// and snow are heights too.
+type Height float32
+
+// A named set of units to render quantities in
+type UnitSystem string
+
+const (
+	Reported UnitSystem = ""         // each quantity in the unit it was reported in
+	Metric   UnitSystem = "metric"   // °C, km/h, hPa, km or m, m, mm
+	Imperial UnitSystem = "imperial" // °F, mph, inHg, miles, ft, inches
+	Aviation UnitSystem = "aviation" // °C, knots, inHg, statute miles, ft, inches
+	SI       UnitSystem = "si"       // K, m/s, Pa, m, m, mm
+)
+
+// The unit each kind of quantity is rendered in, empty for the unit it was reported in
+type Units struct {
+	Temperature, Speed, Pressure, Distance, Height, Depth string
+}
+
+var unitSystems = map[UnitSystem]Units{
+	Reported: {},
+	Metric:   {"C", "KMH", "hPa", "M", "M", "MM"},
+	Imperial: {"F", "MPH", "inHg", "SM", "FT", "IN"},
+	Aviation: {"C", "KT", "inHg", "SM", "FT", "IN"},
+	SI:       {"K", "MPS", "Pa", "M", "M", "MM"},
+}
+
+// Looks up a unit system by name, e.g. "metric"
+func ParseUnitSystem(name string) (system UnitSystem, ok bool) {
+	system = UnitSystem(name)
+	_, ok = unitSystems[system]
+	return
+}
+
+func (this UnitSystem) Units() Units {
+	return unitSystems[this]
+}
+
+var unitLabels = map[string]string{
+	"C":    "°C",
+	"F":    "°F",
+	"K":    "K",
+	"KT":   "KT",
+	"MPS":  "MPS",
+	"KMH":  "KMH",
+	"MPH":  "MPH",
+	"FT":   "ft",
+	"M":    "m",
+	"IN":   "in",
+	"MM":   "mm",
+	"inHg": "inHg",
+	"hPa":  "hPa",
+	"Pa":   "Pa",
+}
+
+// Rounds a value to a tenth and labels it with its unit, e.g. "12.5 KT"
+func formatQuantity(value float32, unit string) string {
+	rounded := math.Round(float64(value*10)) / 10
+	return strconv.FormatFloat(rounded, 'f', -1, 32) + " " + unitLabels[unit]
+}
+
+// Returns the temperature in C, F or K, Celsius if no unit is given
+func (this Temperature) In(unit string) float32 {
+	switch unit {
+	case "F":
+		return float32(this)*9/5 + 32
+	case "K":
+		return float32(this) + 273.15
+	}
+	return float32(this)
+}
+
+func (this Temperature) Format(unit string) string {
+	if unit == "" {
+		unit = "C"
+	}
+	return formatQuantity(this.In(unit), unit)
+}
+
+// Converts a speed in KT, MPS, KMH or MPH to knots
+func speedIn(value float32, unit string) Speed {
+	switch unit {
+	case "MPS":
+		return Speed(float64(value) * KNOTS_PER_MPS)
+	case "KMH":
+		return Speed(float64(value) * KNOTS_PER_KMH)
+	case "MPH":
+		return Speed(float64(value) * KNOTS_PER_MPH)
+	}
+	return Speed(value)
+}
+
+// Returns the speed in KT, MPS, KMH or MPH, knots if no unit is given
+func (this Speed) In(unit string) float32 {
+	switch unit {
+	case "MPS":
+		return float32(float64(this) / KNOTS_PER_MPS)
+	case "KMH":
+		return float32(float64(this) / KNOTS_PER_KMH)
+	case "MPH":
+		return float32(float64(this) / KNOTS_PER_MPH)
+	}
+	return float32(this)
+}
+
+func (this Speed) Format(unit string) string {
+	if unit == "" {
+		unit = "KT"
+	}
+	return formatQuantity(this.In(unit), unit)
+}
+
+// Returns the distance in M, KM, SM or FT, meters if no unit is given
+func (this Distance) In(unit string) float32 {
+	switch unit {
+	case "KM":
+		return float32(this) / 1000
+	case "SM":
+		return float32(float64(this) / METERS_PER_MILE)
+	case "FT":
+		return float32(float64(this) / METERS_PER_FOOT)
+	}
+	return float32(this)
+}
+
+// Describes the distance in statute miles as a fraction, in kilometers,
+// or in meters, switching to kilometers from 5 km, e.g. "1 1/2 miles"
+func (this Distance) Format(unit string) string {
+	switch unit {
+	case "SM":
+		return formatFraction(float64(this.In("SM"))) + " miles"
+	case "KM":
+		return fmt.Sprintf("%v kilometers", math.Round(float64(this.In("KM"))*10)/10)
+	}
+	if this >= 5000 {
+		return this.Format("KM")
+	}
+	return fmt.Sprintf("%v meters", math.Round(float64(this)))
+}
+
+// Returns the height in FT, M, IN or MM, feet if no unit is given
+func (this Height) In(unit string) float32 {
+	switch unit {
+	case "M":
+		return float32(float64(this) * METERS_PER_FOOT)
+	case "IN":
+		return float32(this) * 12
+	case "MM":
+		return float32(float64(this) * METERS_PER_FOOT * 1000)
+	}
+	return float32(this)
+}
+
+func (this Height) Format(unit string) string {
+	if unit == "" {
+		unit = "FT"
+	}
+	return formatQuantity(this.In(unit), unit)
+}
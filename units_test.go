@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestFormatQuantities(t *testing.T) {
+	type quantityTestCase struct {
+		Formatted string
+		Expected  string
+	}
+	testCases := []quantityTestCase{
+		{Temperature(-2).Format(""), "-2 °C"},
+		{Temperature(20).Format("F"), "68 °F"},
+		{Temperature(-40).Format("F"), "-40 °F"},
+		{Temperature(0).Format("K"), "273.2 K"},
+		{Speed(10).Format(""), "10 KT"},
+		{Speed(10).Format("KMH"), "18.5 KMH"},
+		{Speed(10).Format("MPS"), "5.1 MPS"},
+		{Speed(10).Format("MPH"), "11.5 MPH"},
+		{speedIn(8, "MPS").Format("MPS"), "8 MPS"},
+		{speedIn(20, "KMH").Format("KMH"), "20 KMH"},
+		{Height(1500).Format(""), "1500 ft"},
+		{Height(1500).Format("M"), "457.2 m"},
+		{Height(1).Format("IN"), "12 in"},
+		{Height(1).Format("MM"), "304.8 mm"},
+		{Distance(4000).Format(""), "4000 meters"},
+		{Distance(10000).Format(""), "10 kilometers"},
+		{Distance(1609.344).Format("SM"), "1 miles"},
+		{Distance(2414.016).Format("M"), "2414 meters"},
+		{Distance(2414.016).Format("KM"), "2.4 kilometers"},
+	}
+	for _, testCase := range testCases {
+		if testCase.Formatted != testCase.Expected {
+			t.Errorf("Expected %v, got %v", testCase.Expected, testCase.Formatted)
+		}
+	}
+}
+
+func TestParseUnitSystem(t *testing.T) {
+	for _, name := range []string{"", "metric", "imperial", "aviation", "si"} {
+		if _, ok := ParseUnitSystem(name); !ok {
+			t.Errorf("Failed to parse %q but should've succeeded", name)
+		}
+	}
+	if _, ok := ParseUnitSystem("Metric"); ok {
+		t.Error("Parsed Metric but should've failed")
+	}
+	if Imperial.Units().Temperature != "F" || Reported.Units().Pressure != "" {
+		t.Error("Wrong units")
+	}
+}
+
+func TestParseRemarkUnits(t *testing.T) {
+	type remarkUnitsTestCase struct {
+		RemarkValue  string
+		System       UnitSystem
+		ExpectedText string
+	}
+	testCases := []remarkUnitsTestCase{
+		{"SLP982", Metric, "Sea level pressure 998.2 hPa"},
+		{"SLP134", Imperial, "Sea level pressure 29.93 inHg"},
+		{"SLP134", SI, "Sea level pressure 101340 Pa"},
+		{"10142", Imperial, "Max temp in 6 hrs:  57.6 °F"},
+		{"21001", SI, "Min temp in 6 hrs:  273.0 K"},
+		{"60217", Metric, "6-hour precipitation: 55.1 mm"},
+		{"T00501011", Imperial, "Hourly temp:  41.0 °F, dew point:  30.0 °F"},
+	}
+	for _, testCase := range testCases {
+		result := parseRemark(testCase.RemarkValue, testCase.System.Units())
+		if result != testCase.ExpectedText {
+			t.Errorf("Expected %q, got %q", testCase.ExpectedText, result)
+		}
+	}
+}
+
+func TestRunwayVisualRangeFormat(t *testing.T) {
+	runwayRange := parseRunwayVisualRange("R09/0600V1200FT/U")
+	if runwayRange.Format("") != runwayRange.String() {
+		t.Errorf("Wrong reported range %v", runwayRange.Format(""))
+	}
+	if runwayRange.Format("M") != "runway 09 183 to 366 meters, increasing" {
+		t.Errorf("Wrong converted range %v", runwayRange.Format("M"))
+	}
+	runwayRange = parseRunwayVisualRange("R28L/P1500")
+	if runwayRange.Format("FT") != "runway 28L more than 4921 feet" {
+		t.Errorf("Wrong converted range %v", runwayRange.Format("FT"))
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const METERS_PER_MILE = 1609.344
+
+// Prevailing visibility, kept in meters whatever unit it was reported in
+type Visibility struct {
+	Meters      Distance
+	Qualifier   string // "M" for less than, "P" for greater than, or ""
+	Unit        string // unit as reported: SM, KM or M
+	CAVOK       bool
+	Directional []DirectionalVisibility
+}
+
+// Minimum visibility toward one direction, e.g. 1500SW
+type DirectionalVisibility struct {
+	Meters    Distance
+	Direction string
+}
+
+var visibilityRegex = regexp.MustCompile(`^((?P<qualifier>[MP])?(?P<distance>(\d+ )?\d+(\/\d+)?)(?P<unit>SM|KM)` +
+	`|(?P<meters>\d{4})(NDV)?|(?P<cavok>CAVOK)|\/{4}(SM)?)$`)
+
+var directionalVisibilityRegex = regexp.MustCompile(`^(\d{4})(N|NE|E|SE|S|SW|W|NW)$`)
+
+// Parses the prevailing visibility: 10SM, 1 1/2SM, M1/4SM, P6SM, 10KM, 0800, 9999 or CAVOK
+func parseVisibility(visibilityFlat string) (visibility Visibility) {
+	mappable := MappableRegexp{*visibilityRegex}
+	matches := mappable.GetMap(visibilityFlat)
+	switch {
+	case matches["cavok"] != "":
+		visibility.CAVOK = true
+		visibility.Unit = "M"
+		visibility.Meters = 10000
+		visibility.Qualifier = "P"
+	case matches["meters"] == "9999":
+		visibility.Unit = "M"
+		visibility.Meters = 10000
+		visibility.Qualifier = "P"
+	case matches["meters"] != "":
+		visibility.Unit = "M"
+		visibility.Meters = Distance(parseSignedFloat(matches["meters"]))
+	default:
+		visibility.Unit = matches["unit"]
+		visibility.Qualifier = matches["qualifier"]
+		distance := parseFraction(matches["distance"])
+		if visibility.Unit == "SM" {
+			visibility.Meters = Distance(distance * METERS_PER_MILE)
+		} else {
+			visibility.Meters = Distance(distance * 1000)
+		}
+	}
+	return
+}
+
+func parseDirectionalVisibility(directionalFlat string) (directional DirectionalVisibility) {
+	matches := directionalVisibilityRegex.FindStringSubmatch(directionalFlat)
+	directional.Meters = Distance(parseSignedFloat(matches[1]))
+	directional.Direction = matches[2]
+	return
+}
+
+// Parses a whole number, a fraction or a mixed number like "1 1/2"
+func parseFraction(fractionFlat string) (value float64) {
+	for _, part := range strings.Fields(fractionFlat) {
+		if numerator, denominator, ok := strings.Cut(part, "/"); ok {
+			top, _ := strconv.ParseFloat(numerator, 64)
+			bottom, _ := strconv.ParseFloat(denominator, 64)
+			if bottom != 0 {
+				value += top / bottom
+			}
+		} else {
+			whole, _ := strconv.ParseFloat(part, 64)
+			value += whole
+		}
+	}
+	return
+}
+
+// Formats a value as a mixed number to the nearest sixteenth, e.g. "1 1/2"
+func formatFraction(value float64) string {
+	sixteenths := int(math.Round(value * 16))
+	whole, numerator, denominator := sixteenths/16, sixteenths%16, 16
+	for numerator != 0 && numerator%2 == 0 {
+		numerator /= 2
+		denominator /= 2
+	}
+	switch {
+	case numerator == 0:
+		return strconv.Itoa(whole)
+	case whole == 0:
+		return fmt.Sprintf("%d/%d", numerator, denominator)
+	}
+	return fmt.Sprintf("%d %d/%d", whole, numerator, denominator)
+}
+
+func (this Visibility) Miles() float32 {
+	return this.Meters.In("SM")
+}
+
+func (this Visibility) Kilometers() float32 {
+	return this.Meters.In("KM")
+}
+
+// Describes the visibility in the given unit, SM, KM or M, or in the
+// unit it was reported in if none is given, e.g. "less than 1/4 miles".
+// Directional minimums reported without a prevailing visibility are in meters.
+func (this Visibility) Format(unit string) string {
+	if this.Unit == "" {
+		if unit == "" {
+			unit = "M"
+		}
+		distance := "not reported"
+		for _, directional := range this.Directional {
+			distance += fmt.Sprintf(", %s to the %s", directional.Meters.Format(unit), directional.Direction)
+		}
+		return distance
+	}
+	if unit == "" {
+		unit = this.Unit
+	}
+	distance := this.Meters.Format(unit)
+	switch this.Qualifier {
+	case "M":
+		distance = "less than " + distance
+	case "P":
+		distance = "more than " + distance
+	}
+	if this.CAVOK {
+		distance = "CAVOK, " + distance
+	}
+	for _, directional := range this.Directional {
+		distance += fmt.Sprintf(", %s to the %s", directional.Meters.Format(unit), directional.Direction)
+	}
+	return distance
+}
+
+func (this Visibility) String() string {
+	return this.Format("")
+}
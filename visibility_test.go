@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+type VisibilityTestCase struct {
+	VisibilityValue   string
+	ExpectedMeters    Distance
+	ExpectedQualifier string
+	ExpectedResult    string
+}
+
+func TestParseVisibility(t *testing.T) {
+	testCases := []VisibilityTestCase{
+		VisibilityTestCase{"10SM", 16093.44, "", "10 miles"},
+		VisibilityTestCase{"1 1/2SM", 2414.016, "", "1 1/2 miles"},
+		VisibilityTestCase{"M1/4SM", 402.336, "M", "less than 1/4 miles"},
+		VisibilityTestCase{"P6SM", 9656.064, "P", "more than 6 miles"},
+		VisibilityTestCase{"10KM", 10000, "", "10 kilometers"},
+		VisibilityTestCase{"0800", 800, "", "800 meters"},
+		VisibilityTestCase{"9999", 10000, "P", "more than 10 kilometers"},
+		VisibilityTestCase{"CAVOK", 10000, "P", "CAVOK, more than 10 kilometers"},
+	}
+	for _, testCase := range testCases {
+		visibility := parseVisibility(testCase.VisibilityValue)
+		if visibility.Meters != testCase.ExpectedMeters || visibility.Qualifier != testCase.ExpectedQualifier {
+			t.Errorf("Wrong visibility for %v: %+v", testCase.VisibilityValue, visibility)
+		}
+		if visibility.String() != testCase.ExpectedResult {
+			t.Errorf("Invalid visibility.  Expected %v, got %v", testCase.ExpectedResult, visibility)
+		}
+	}
+}
+
+func TestParseMetarVisibility(t *testing.T) {
+	const testMetar = "KORD 210051Z 15007KT 1 1/2SM BR OVC006 05/04 A3010"
+	metar, err := ParseMetar(testMetar, testOptions)
+	t.Logf("Received %+v, %v", metar, err)
+	if err != nil {
+		t.Error("Failed to parse but should've succeeded")
+	}
+	if metar.Visibility.Meters != 2414.016 || metar.Visibility.String() != "1 1/2 miles" {
+		t.Errorf("Wrong visibility %v", metar.Visibility)
+	}
+	if len(metar.Phenomena) != 1 {
+		t.Error("Weather after the visibility not decoded")
+	}
+}
+
+func TestParseMetarDirectionalVisibility(t *testing.T) {
+	const testMetar = "EGLL 210050Z 24010KT 4000 1500SW 0800NE BR BKN004 08/07 A3010"
+	metar, err := ParseMetar(testMetar, testOptions)
+	t.Logf("Received %+v, %v", metar, err)
+	if err != nil {
+		t.Error("Failed to parse but should've succeeded")
+	}
+	if metar.Visibility.Meters != 4000 {
+		t.Error("Wrong prevailing visibility")
+	}
+	if len(metar.Visibility.Directional) != 2 || metar.Visibility.Directional[0] != (DirectionalVisibility{1500, "SW"}) {
+		t.Errorf("Wrong directional visibility %+v", metar.Visibility.Directional)
+	}
+	if metar.Visibility.String() != "4000 meters, 1500 meters to the SW, 800 meters to the NE" {
+		t.Errorf("Wrong description %v", metar.Visibility)
+	}
+}
+
+func TestParseMetarDirectionalVisibilityOnly(t *testing.T) {
+	metar, err := ParseMetar("EGLL 211150Z 24015KT //// 1500SW BKN015 12/08 Q1013", testOptions)
+	if err != nil || metar.Visibility == nil || len(metar.Visibility.Directional) != 1 {
+		t.Fatalf("Wrong visibility %+v, %v", metar.Visibility, err)
+	}
+	if metar.Visibility.String() != "not reported, 1500 meters to the SW" {
+		t.Errorf("Wrong description %v", metar.Visibility)
+	}
+	details := GetDetailMetar(metar)
+	if !strings.Contains(details, "Visibility    : not reported, 1500 meters to the SW\n") {
+		t.Errorf("Wrong visibility details %v", details)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// A single present-weather group, e.g. -FZRA or VCTS
+type Phenomenon struct {
+	Intensity     string   // "-", "+", "VC" or "" for moderate
+	Descriptor    string   // MI, BC, PR, DR, BL, SH, TS, FZ
+	Precipitation []string // DZ, RA, SN, SG, IC, PL, GR, GS, UP
+	Obscuration   string   // BR, FG, FU, VA, DU, SA, HZ, PY
+	Other         string   // PO, SQ, FC, SS, DS
+}
+
+// All of the present-weather groups in a report
+type Phenomena []Phenomenon
+
+var weatherIntensities = map[string]string{
+	"-": "light",
+	"+": "heavy",
+}
+
+var weatherDescriptors = map[string]string{
+	"MI": "shallow",
+	"BC": "patches of",
+	"PR": "partial",
+	"DR": "low drifting",
+	"BL": "blowing",
+	"SH": "showers",
+	"TS": "thunderstorm",
+	"FZ": "freezing",
+}
+
+var weatherPrecipitation = map[string]string{
+	"DZ": "drizzle",
+	"RA": "rain",
+	"SN": "snow",
+	"SG": "snow grains",
+	"IC": "ice crystals",
+	"PL": "ice pellets",
+	"GR": "hail",
+	"GS": "small hail",
+	"UP": "unknown precipitation",
+}
+
+var weatherObscurations = map[string]string{
+	"BR": "mist",
+	"FG": "fog",
+	"FU": "smoke",
+	"VA": "volcanic ash",
+	"DU": "widespread dust",
+	"SA": "sand",
+	"HZ": "haze",
+	"PY": "spray",
+}
+
+var weatherOther = map[string]string{
+	"PO": "dust whirls",
+	"SQ": "squalls",
+	"FC": "funnel cloud",
+	"SS": "sandstorm",
+	"DS": "duststorm",
+}
+
+// The codes making up a present-weather group, in the order they appear
+const (
+	descriptorCodes    = `MI|BC|PR|DR|BL|SH|TS|FZ`
+	precipitationCodes = `DZ|RA|SN|SG|IC|PL|GR|GS|UP`
+	obscurationCodes   = `BR|FG|FU|VA|DU|SA|HZ|PY`
+	otherCodes         = `PO|SQ|FC|SS|DS`
+)
+
+// A present-weather group, which needs at least one code besides its
+// intensity, so a bare VC isn't one
+var weatherRegex = regexp.MustCompile(`^([-+]|VC)?(` +
+	`(` + descriptorCodes + `)(` + precipitationCodes + `)*(` + obscurationCodes + `)?(` + otherCodes + `)?|` +
+	`(` + precipitationCodes + `)+(` + obscurationCodes + `)?(` + otherCodes + `)?|` +
+	`(` + obscurationCodes + `)(` + otherCodes + `)?|` +
+	`(` + otherCodes + `))$`)
+
+// Picks apart a group weatherRegex has matched
+var weatherPartsRegex = regexp.MustCompile(`^(?P<intensity>[-+]|VC)?(?P<descriptor>` + descriptorCodes + `)?` +
+	`(?P<precipitation>(` + precipitationCodes + `)*)(?P<obscuration>` + obscurationCodes + `)?` +
+	`(?P<other>` + otherCodes + `)?$`)
+
+var recentWeatherRegex = regexp.MustCompile(`^RE` + strings.TrimPrefix(weatherRegex.String(), "^"))
+
+// Decodes a single present-weather group
+func parseWeather(weatherFlat string) (phenomenon Phenomenon) {
+	mappable := MappableRegexp{*weatherPartsRegex}
+	matches := mappable.GetMap(weatherFlat)
+	phenomenon.Intensity = matches["intensity"]
+	phenomenon.Descriptor = matches["descriptor"]
+	for i := 0; i < len(matches["precipitation"]); i += 2 {
+		phenomenon.Precipitation = append(phenomenon.Precipitation, matches["precipitation"][i:i+2])
+	}
+	phenomenon.Obscuration = matches["obscuration"]
+	phenomenon.Other = matches["other"]
+	return
+}
+
+// Describes the group in English, e.g. "light freezing rain"
+func (this Phenomenon) String() string {
+	var nouns []string
+	for _, code := range this.Precipitation {
+		nouns = append(nouns, weatherPrecipitation[code])
+	}
+	if this.Obscuration != "" {
+		nouns = append(nouns, weatherObscurations[this.Obscuration])
+	}
+	if this.Other == "FC" && this.Intensity == "+" {
+		nouns = append(nouns, "tornado")
+	} else if this.Other != "" {
+		nouns = append(nouns, weatherOther[this.Other])
+	}
+	phrase := strings.Join(nouns, " and ")
+	intensity := weatherIntensities[this.Intensity]
+	if this.Other == "FC" {
+		intensity = ""
+	}
+
+	switch this.Descriptor {
+	case "TS":
+		if phrase == "" {
+			phrase = "thunderstorm"
+		} else {
+			phrase = "thunderstorm with " + joinWords(intensity, phrase)
+		}
+	case "SH":
+		phrase = joinWords(intensity, phrase, "showers")
+	default:
+		phrase = joinWords(intensity, weatherDescriptors[this.Descriptor], phrase)
+	}
+
+	if this.Intensity == "VC" {
+		phrase += " in the vicinity"
+	}
+	return phrase
+}
+
+// Describes all of the groups, e.g. "light freezing rain, mist"
+func (this Phenomena) String() string {
+	var phrases []string
+	for _, phenomenon := range this {
+		phrases = append(phrases, phenomenon.String())
+	}
+	return strings.Join(phrases, ", ")
+}
+
+// Joins the non-empty words with single spaces
+func joinWords(words ...string) string {
+	var nonEmpty []string
+	for _, word := range words {
+		if word != "" {
+			nonEmpty = append(nonEmpty, word)
+		}
+	}
+	return strings.Join(nonEmpty, " ")
+}
+
+// Upper-cases the first letter, to start a sentence
+func capitalize(phrase string) string {
+	if phrase == "" {
+		return phrase
+	}
+	return strings.ToUpper(phrase[:1]) + phrase[1:]
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+type WeatherTestCase struct {
+	WeatherValue   string
+	ExpectedResult string
+}
+
+func TestParseWeather(t *testing.T) {
+	testCases := []WeatherTestCase{
+		WeatherTestCase{"-FZRA", "light freezing rain"},
+		WeatherTestCase{"+FZRA", "heavy freezing rain"},
+		WeatherTestCase{"BR", "mist"},
+		WeatherTestCase{"-TSRA", "thunderstorm with light rain"},
+		WeatherTestCase{"TS", "thunderstorm"},
+		WeatherTestCase{"-SHRA", "light rain showers"},
+		WeatherTestCase{"VCSH", "showers in the vicinity"},
+		WeatherTestCase{"BCFG", "patches of fog"},
+		WeatherTestCase{"BLSN", "blowing snow"},
+		WeatherTestCase{"RASN", "rain and snow"},
+		WeatherTestCase{"+FC", "tornado"},
+		WeatherTestCase{"SQ", "squalls"},
+	}
+	for _, testCase := range testCases {
+		result := parseWeather(testCase.WeatherValue).String()
+		if result != testCase.ExpectedResult {
+			t.Errorf("Invalid weather.  Expected %v, got %v", testCase.ExpectedResult, result)
+		}
+	}
+}
+
+func TestParseWeatherStructure(t *testing.T) {
+	phenomenon := parseWeather("-SHRASN")
+	t.Logf("Received %+v", phenomenon)
+	if phenomenon.Intensity != "-" || phenomenon.Descriptor != "SH" {
+		t.Error("Wrong intensity or descriptor")
+	}
+	if len(phenomenon.Precipitation) != 2 || phenomenon.Precipitation[1] != "SN" {
+		t.Error("Wrong precipitation")
+	}
+}
+
+func TestPhenomenaString(t *testing.T) {
+	phenomena := Phenomena{parseWeather("-FZRA"), parseWeather("BR")}
+	if phenomena.String() != "light freezing rain, mist" {
+		t.Errorf("Wrong description %v", phenomena)
+	}
+}
+
+func TestWeatherRegex(t *testing.T) {
+	for _, value := range []string{"-FZRA", "VCSH", "TS", "+TSRAGR", "BR", "VCFG", "+FC", "-RASNBR"} {
+		if !weatherRegex.MatchString(value) {
+			t.Errorf("%v should be a weather group", value)
+		}
+	}
+	// an intensity alone isn't a phenomenon
+	for _, value := range []string{"VC", "-", "+", "", "RATS"} {
+		if weatherRegex.MatchString(value) {
+			t.Errorf("%v shouldn't be a weather group", value)
+		}
+	}
+}
+
+func TestNoWeatherDetails(t *testing.T) {
+	metar, _ := ParseMetar("KORD 210051Z 15007KT 10SM OVC060 05/01 A3010", testOptions)
+	if details := GetDetailMetar(metar); !strings.Contains(details, "Weather       : none\n") {
+		t.Errorf("Wrong weather details %v", details)
+	}
+	metar, _ = ParseMetar("KORD 210051Z 15007KT 10SM VC OVC060 05/01 A3010", testOptions)
+	if len(metar.Phenomena) != 0 || len(metar.Unparsed) != 1 || metar.Unparsed[0].Token != "VC" {
+		t.Errorf("A bare VC shouldn't be weather %+v %+v", metar.Phenomena, metar.Unparsed)
+	}
+}